@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"currency-converter/repository"
+	"currency-converter/service"
+
+	"gorm.io/gorm"
+)
+
+// createAdmin bootstraps the first admin user:
+//
+//	currency-converter create-admin -email admin@example.com -password ...
+//
+// the password can also come from ADMIN_PASSWORD to keep it out of the shell history
+func createAdmin(ctx context.Context, dbConn *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	email := fs.String("email", "", "email of the admin user")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "password of the admin user, ignored if the user already exists")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+	if len(*password) < 6 {
+		return errors.New("-password must be at least 6 characters")
+	}
+
+	userService := service.NewUserService(repository.NewUserRepository(dbConn), nil, nil, nil)
+	userID, err := userService.BootstrapAdmin(ctx, *email, *password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("user %d (%s) is now an admin\n", userID, *email)
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"currency-converter/config"
+	"currency-converter/db"
+	"currency-converter/security"
+
+	"gorm.io/gorm"
+)
+
+// runCommand runs a one-off command instead of the server
+func runCommand(cfg config.Config, name string, args []string) error {
+	switch name {
+	case "create-admin":
+		dbConn, err := connectAndCheck(cfg)
+		if err != nil {
+			return err
+		}
+		return createAdmin(context.Background(), dbConn, args)
+	case "seed-currencies":
+		dbConn, err := connectAndCheck(cfg)
+		if err != nil {
+			return err
+		}
+		return seedCurrencies(context.Background(), dbConn, args)
+	case "migrate":
+		dbConn, err := db.ConnectDB(cfg.DBUrl)
+		if err != nil {
+			return fmt.Errorf("error in connecting to DB: %w", err)
+		}
+		return runMigrate(dbConn, args)
+	case "gen-signing-key":
+		return genSigningKey(cfg, args)
+	}
+	return fmt.Errorf("unknown command %q, expected migrate, create-admin, seed-currencies or gen-signing-key", name)
+}
+
+// connectAndCheck connects to the DB, refusing a schema which is missing migrations
+func connectAndCheck(cfg config.Config) (*gorm.DB, error) {
+	dbConn, err := db.ConnectDB(cfg.DBUrl)
+	if err != nil {
+		return nil, fmt.Errorf("error in connecting to DB: %w", err)
+	}
+	if err := db.CheckVersion(dbConn); err != nil {
+		return nil, err
+	}
+	return dbConn, nil
+}
+
+// genSigningKey adds a new Ed25519 key to AUTH_KEYS_DIR:
+//
+//	currency-converter gen-signing-key [-kid 2024-06-01]
+//
+// with the default LoadKeyRing rule the newest date based kid becomes the active key on restart
+func genSigningKey(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("gen-signing-key", flag.ContinueOnError)
+	kid := fs.String("kid", time.Now().UTC().Format("2006-01-02"), "id of the new key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *kid == "" {
+		return errors.New("-kid is required")
+	}
+
+	path, err := security.GenerateSigningKey(cfg.AuthConfig.KeysDir, *kid)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("signing key %s written to %s\n", *kid, path)
+	return nil
+}
@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"currency-converter/config"
 	"currency-converter/controller"
 	"currency-converter/db"
 	"currency-converter/middleware"
+	"currency-converter/provider"
 	"currency-converter/repository"
 	"currency-converter/router"
+	"currency-converter/scheduler"
 	"currency-converter/security"
 	"currency-converter/service"
 	"currency-converter/utils"
@@ -25,15 +34,36 @@ func main() {
 		log.Fatalf("error in loading config: %v", err)
 	}
 
+	// one-off commands, the server is started when none is given
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("error in running %s: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// Load the token signing keys
+	keyRing, err := security.LoadKeyRing(cfg.AuthConfig.KeysDir, cfg.AuthConfig.SigningKeyID)
+	if err != nil {
+		log.Fatalf("error in loading signing keys: %v", err)
+	}
+
 	// Connect to DB
 	dbConn, err := db.ConnectDB(cfg.DBUrl)
 	if err != nil {
 		log.Fatalf("error in connecting to DB: %v", err)
 	}
 
-	// do all migrations in DB
-	if err := db.Migrate(dbConn); err != nil {
-		log.Fatalf("error in migration in DB: %v", err)
+	// migrations are run with the migrate command, refuse a schema older than this build
+	if err := db.CheckVersion(dbConn); err != nil {
+		log.Fatalf("error in checking DB schema: %v", err)
+	}
+
+	// fill an empty environment with the ISO 4217 catalogue
+	if cfg.SeedCurrencies {
+		if err := seedCurrencies(context.Background(), dbConn, nil); err != nil {
+			log.Fatalf("error in seeding currencies: %v", err)
+		}
 	}
 
 	// Inject dependencies -> 
@@ -41,34 +71,89 @@ func main() {
 	
 	// create repositories
 	userRepo := repository.NewUserRepository(dbConn)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(dbConn)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(dbConn)
+	apiKeyRepo := repository.NewAPIKeyRepository(dbConn)
 	currencyRepo := repository.NewCurrencyRepository(dbConn)
 	exchangeRateRepo := repository.NewExchangeRateRepository(dbConn)
+	syncRunRepo := repository.NewSyncRunRepository(dbConn)
+	quarantineRepo := repository.NewQuarantineRepository(dbConn)
+	exchangeRateChangeRepo := repository.NewExchangeRateChangeRepository(dbConn)
+	auditRepo := repository.NewAuditRepository(dbConn)
 
 	// create services
-	tokenService := security.NewTokenService(&cfg.AuthConfig)
+	tokenService := security.NewTokenService(&cfg.AuthConfig, keyRing, revokedTokenRepo)
 	httpClient := utils.NewHTTPClient()
+	rateProvider, err := provider.New(cfg.ProviderConfig, httpClient)
+	if err != nil {
+		log.Fatalf("error in creating exchange rate provider: %v", err)
+	}
 
-	userService := service.NewUserService(userRepo, tokenService)
+	userService := service.NewUserService(userRepo, refreshTokenRepo, revokedTokenRepo, tokenService)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo)
 	currencyService := service.NewCurrencyService(currencyRepo)
-	exchangeRateService := service.NewExchangeRateService(exchangeRateRepo, currencyRepo, httpClient, cfg.ExchangeRateAPI)
-	conversionService := service.NewConversionService(currencyRepo, exchangeRateRepo)
+	exchangeRateService := service.NewExchangeRateService(exchangeRateRepo, currencyRepo, quarantineRepo, rateProvider, cfg.SyncConfig.TargetCurrencies, cfg.RateGuard)
+	syncRunService := service.NewSyncRunService(syncRunRepo, exchangeRateService)
+	quarantineService := service.NewQuarantineService(quarantineRepo)
+	exchangeRateChangeService := service.NewExchangeRateChangeService(exchangeRateChangeRepo, exchangeRateRepo, exchangeRateService)
+	auditService := service.NewAuditService(auditRepo)
+	conversionService := service.NewConversionService(currencyRepo, exchangeRateRepo, cfg.PivotCurrency, cfg.MoneyConfig)
 
 	// create controllers
 	userController := controller.NewUserController(userService)
+	apiKeyController := controller.NewAPIKeyController(apiKeyService)
+	jwksController := controller.NewJWKSController(tokenService)
+	auditController := controller.NewAuditController(auditService)
 	currencyController := controller.NewCurrencyController(currencyService)
 	exchangeRateController := controller.NewExchangeRateController(exchangeRateService)
 	conversionController := controller.NewConversionController(conversionService)
+	syncRunController := controller.NewSyncRunController(syncRunService)
+	quarantineController := controller.NewQuarantineController(quarantineService)
+	exchangeRateChangeController := controller.NewExchangeRateChangeController(exchangeRateChangeService)
 
 	// create auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(tokenService)
+	authMiddleware := middleware.NewAuthMiddleware(tokenService, apiKeyService)
 
 	// Setup Routes
-	r := router.SetupRouter(authMiddleware, userController, currencyController, exchangeRateController, conversionController)
+	r := router.SetupRouter(authMiddleware, userController, currencyController, exchangeRateController, conversionController, syncRunController, quarantineController, exchangeRateChangeController, apiKeyController, jwksController, auditController)
+
+	// stop everything on SIGINT / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background sync, disabled when no schedule is configured
+	var syncScheduler *scheduler.Scheduler
+	if cfg.SyncConfig.Schedule != "" {
+		syncScheduler, err = scheduler.New(cfg.SyncConfig.Schedule, cfg.SyncConfig.BaseCurrencies, cfg.SyncConfig.Jitter, syncRunService)
+		if err != nil {
+			log.Fatalf("error in creating sync scheduler: %v", err)
+		}
+		syncScheduler.Start(ctx)
+		log.Printf("sync scheduler started with schedule %q for %v", cfg.SyncConfig.Schedule, cfg.SyncConfig.BaseCurrencies)
+	}
 
-	// Run Server 
-	// In production we should do graceful shutdown of server
-	log.Printf("server listening on Port : %v", cfg.Port)
-	if err := r.Run(fmt.Sprintf(":%v", cfg.Port)); err != nil {
-		log.Fatalf("error in runnin server: %v", err)
+	// Run Server
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%v", cfg.Port),
+		Handler: r,
+	}
+	go func() {
+		log.Printf("server listening on Port : %v", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("error in runnin server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("shutting down")
+
+	// graceful shutdown, let in flight requests finish and wait for the scheduler to exit
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error in shutting down server: %v", err)
+	}
+	if syncScheduler != nil {
+		syncScheduler.Stop()
 	}
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"currency-converter/db"
+
+	"gorm.io/gorm"
+)
+
+// runMigrate moves the schema between the embedded migrations:
+//
+//	currency-converter migrate up
+//	currency-converter migrate down
+//	currency-converter migrate status
+//	currency-converter migrate to 3
+//
+// down rolls back one migration, down to the baseline which can't be rolled back.
+// A database created before versioned migrations is adopted by migrate up, the baseline only adds what it misses
+func runMigrate(dbConn *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected up, down, status or to <version>")
+	}
+
+	var ran []db.Migration
+	var err error
+	switch args[0] {
+	case "up":
+		ran, err = db.MigrateUp(dbConn)
+	case "down":
+		ran, err = db.MigrateDown(dbConn)
+	case "to":
+		if len(args) < 2 {
+			return errors.New("migrate to needs a version")
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		ran, err = db.MigrateTo(dbConn, version)
+	case "status":
+		return printMigrationStatus(dbConn)
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q, expected up, down, status or to <version>", args[0])
+	}
+
+	// report what ran even when a later step failed, those steps are committed
+	for _, m := range ran {
+		fmt.Printf("ran %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(ran) == 0 {
+		fmt.Println("nothing to migrate")
+	}
+
+	version, err := db.CurrentVersion(dbConn)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema is at version %d\n", version)
+	return nil
+}
+
+func printMigrationStatus(dbConn *gorm.DB) error {
+	statuses, err := db.MigrationStatuses(dbConn)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"currency-converter/db"
+	"currency-converter/repository"
+
+	"gorm.io/gorm"
+)
+
+// seedCurrencies upserts the embedded ISO 4217 catalogue:
+//
+//	currency-converter seed-currencies
+//
+// it is safe to rerun, and also runs on startup when SEED_CURRENCIES=true
+func seedCurrencies(ctx context.Context, dbConn *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("seed-currencies", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	currencies, err := db.ISO4217Currencies()
+	if err != nil {
+		return err
+	}
+
+	result, err := repository.NewCurrencyRepository(dbConn).Seed(ctx, currencies)
+	if err != nil {
+		return fmt.Errorf("error in seeding currencies: %w", err)
+	}
+
+	// historic and withdrawn codes are kept for old data but inserted as inactive
+	var historic []string
+	for _, currency := range currencies {
+		if !currency.IsActive {
+			historic = append(historic, currency.Code)
+		}
+	}
+
+	log.Printf("seeded %d ISO 4217 currencies: %d inserted, %d updated, %d unchanged",
+		len(currencies), len(result.Inserted), len(result.Updated), len(result.Unchanged))
+	if len(result.Updated) > 0 {
+		log.Printf("filled in ISO details of: %s", strings.Join(result.Updated, ", "))
+	}
+	log.Printf("historic or withdrawn, inactive when inserted: %s", strings.Join(historic, ", "))
+	return nil
+}
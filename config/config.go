@@ -1,23 +1,93 @@
 package config
 
 import (
+	"currency-converter/decimal"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type AuthConfig struct {
-	Secret    string
-	ExpiryMin int
+	KeysDir       string        // directory of the Ed25519 signing keys, see security.KeyRing
+	SigningKeyID  string        // kid of the active signing key, the greatest kid when empty
+	ExpiryMin     int           // lifetime of access tokens
+	RefreshExpiry time.Duration // lifetime of refresh tokens
+}
+
+// supported exchange rate providers
+const (
+	ProviderExchangeRateAPI = "exchangerate-api"
+	ProviderECB             = "ecb"
+	ProviderFile            = "file"
+)
+
+// sync modes
+const (
+	SyncModeFailover  = "failover"  // first provider which answers wins
+	SyncModeConsensus = "consensus" // every provider is asked and the quotes are combined
+)
+
+// ProviderConfig lists the providers in the order they are tried
+type ProviderConfig struct {
+	Mode             string
+	Names            []string
+	ExchangeRateAPI  string
+	ECBURL           string
+	FilePath         string
+	MaxRetries       int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	ConsensusMethod          string
+	ConsensusMaxDeviationBps decimal.Decimal
+	ConsensusTrimPercent     int
+	ConsensusMinQuotes       int
+}
+
+// SyncConfig drives the rate sync, an empty Schedule disables the background sync
+// and an empty TargetCurrencies syncs every active currency
+type SyncConfig struct {
+	Schedule         string
+	BaseCurrencies   []string
+	TargetCurrencies []string
+	Jitter           time.Duration
+}
+
+// PairLimit bounds a rate change of one pair, nil fields are not checked
+type PairLimit struct {
+	MaxMovePct *decimal.Decimal
+	MinRate    *decimal.Decimal
+	MaxRate    *decimal.Decimal
+}
+
+// RateGuardConfig holds the limits a new rate has to respect to go live,
+// Pairs is keyed by "FROM/TO" and falls back to DefaultMaxMovePct
+type RateGuardConfig struct {
+	DefaultMaxMovePct *decimal.Decimal
+	Pairs             map[string]PairLimit
+}
+
+type MoneyConfig struct {
+	RoundingMode  decimal.RoundingMode
+	RoundingScale int
 }
 
 type Config struct {
-	Port            int
-	DBUrl           string
-	ExchangeRateAPI string
-	AuthConfig      AuthConfig
+	Port           int
+	DBUrl          string
+	PivotCurrency  string
+	ProviderConfig ProviderConfig
+	AuthConfig     AuthConfig
+	MoneyConfig    MoneyConfig
+	SyncConfig     SyncConfig
+	RateGuard      RateGuardConfig
+	SeedCurrencies bool // upsert the ISO 4217 catalogue on startup
 }
 
 func LoadConfig() (Config, error) {
@@ -30,18 +100,119 @@ func LoadConfig() (Config, error) {
 		return Config{}, fmt.Errorf("invalid APP_PORT: %w", err)
 	}
 
-	expiryMin, err := strconv.Atoi(getEnv("AUTH_EXPIRY_MIN", "60"))
+	expiryMin, err := strconv.Atoi(getEnv("AUTH_EXPIRY_MIN", "15"))
 	if err != nil {
 		return Config{}, fmt.Errorf("invalid AUTH_EXPIRY_MIN: %w", err)
 	}
 
+	refreshExpiryHours, err := strconv.Atoi(getEnv("AUTH_REFRESH_EXPIRY_HOURS", "168"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid AUTH_REFRESH_EXPIRY_HOURS: %w", err)
+	}
+
+	roundingMode, err := decimal.ParseRoundingMode(getEnv("ROUNDING_MODE", string(decimal.HalfEven)))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ROUNDING_MODE: %w", err)
+	}
+
+	roundingScale, err := strconv.Atoi(getEnv("ROUNDING_SCALE", "2"))
+	if err != nil || roundingScale < 0 {
+		return Config{}, fmt.Errorf("invalid ROUNDING_SCALE: %q", getEnv("ROUNDING_SCALE", "2"))
+	}
+
+	maxRetries, err := strconv.Atoi(getEnv("PROVIDER_MAX_RETRIES", "2"))
+	if err != nil || maxRetries < 0 {
+		return Config{}, fmt.Errorf("invalid PROVIDER_MAX_RETRIES: %q", getEnv("PROVIDER_MAX_RETRIES", "2"))
+	}
+
+	retryBaseMs, err := strconv.Atoi(getEnv("PROVIDER_RETRY_BASE_MS", "200"))
+	if err != nil || retryBaseMs < 0 {
+		return Config{}, fmt.Errorf("invalid PROVIDER_RETRY_BASE_MS: %q", getEnv("PROVIDER_RETRY_BASE_MS", "200"))
+	}
+
+	retryMaxMs, err := strconv.Atoi(getEnv("PROVIDER_RETRY_MAX_MS", "5000"))
+	if err != nil || retryMaxMs < retryBaseMs {
+		return Config{}, fmt.Errorf("invalid PROVIDER_RETRY_MAX_MS: %q", getEnv("PROVIDER_RETRY_MAX_MS", "5000"))
+	}
+
+	breakerThreshold, err := strconv.Atoi(getEnv("PROVIDER_BREAKER_THRESHOLD", "3"))
+	if err != nil || breakerThreshold < 1 {
+		return Config{}, fmt.Errorf("invalid PROVIDER_BREAKER_THRESHOLD: %q", getEnv("PROVIDER_BREAKER_THRESHOLD", "3"))
+	}
+
+	breakerCooldownSec, err := strconv.Atoi(getEnv("PROVIDER_BREAKER_COOLDOWN_SEC", "60"))
+	if err != nil || breakerCooldownSec < 0 {
+		return Config{}, fmt.Errorf("invalid PROVIDER_BREAKER_COOLDOWN_SEC: %q", getEnv("PROVIDER_BREAKER_COOLDOWN_SEC", "60"))
+	}
+
+	maxDeviationBps, err := decimal.Parse(getEnv("CONSENSUS_MAX_DEVIATION_BPS", "100"))
+	if err != nil || maxDeviationBps.Sign() < 0 {
+		return Config{}, fmt.Errorf("invalid CONSENSUS_MAX_DEVIATION_BPS: %q", getEnv("CONSENSUS_MAX_DEVIATION_BPS", "100"))
+	}
+
+	trimPercent, err := strconv.Atoi(getEnv("CONSENSUS_TRIM_PERCENT", "20"))
+	if err != nil || trimPercent < 0 || trimPercent >= 50 {
+		return Config{}, fmt.Errorf("invalid CONSENSUS_TRIM_PERCENT: %q", getEnv("CONSENSUS_TRIM_PERCENT", "20"))
+	}
+
+	minQuotes, err := strconv.Atoi(getEnv("CONSENSUS_MIN_QUOTES", "2"))
+	if err != nil || minQuotes < 1 {
+		return Config{}, fmt.Errorf("invalid CONSENSUS_MIN_QUOTES: %q", getEnv("CONSENSUS_MIN_QUOTES", "2"))
+	}
+
+	rateGuard, err := parseRateGuard(getEnv("RATE_GUARD_MAX_MOVE_PCT", "10"), getEnv("RATE_GUARD_PAIRS", ""))
+	if err != nil {
+		return Config{}, err
+	}
+
+	jitterSec, err := strconv.Atoi(getEnv("SYNC_JITTER_SEC", "30"))
+	if err != nil || jitterSec < 0 {
+		return Config{}, fmt.Errorf("invalid SYNC_JITTER_SEC: %q", getEnv("SYNC_JITTER_SEC", "30"))
+	}
+
+	seedCurrencies, err := strconv.ParseBool(getEnv("SEED_CURRENCIES", "false"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid SEED_CURRENCIES: %q", getEnv("SEED_CURRENCIES", "false"))
+	}
+
 	cfg := Config{
-		Port:  appPort,
-		DBUrl: getEnv("DB_URL", ""),
-		ExchangeRateAPI: getEnv("EXCHANGE_RATE_API", ""),
+		Port:          appPort,
+		DBUrl:         getEnv("DB_URL", ""),
+		PivotCurrency: strings.ToUpper(getEnv("PIVOT_CURRENCY", "USD")),
+		ProviderConfig: ProviderConfig{
+			Mode:             strings.ToLower(getEnv("SYNC_MODE", SyncModeFailover)),
+			Names:            getEnvNames("RATE_PROVIDERS", ProviderExchangeRateAPI),
+			ExchangeRateAPI:  getEnv("EXCHANGE_RATE_API", ""),
+			ECBURL:           getEnv("ECB_RATES_URL", "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"),
+			FilePath:         getEnv("RATE_FILE_PATH", ""),
+			MaxRetries:       maxRetries,
+			RetryBaseDelay:   time.Duration(retryBaseMs) * time.Millisecond,
+			RetryMaxDelay:    time.Duration(retryMaxMs) * time.Millisecond,
+			BreakerThreshold: breakerThreshold,
+			BreakerCooldown:  time.Duration(breakerCooldownSec) * time.Second,
+
+			ConsensusMethod:          strings.ToLower(getEnv("CONSENSUS_METHOD", "median")),
+			ConsensusMaxDeviationBps: maxDeviationBps,
+			ConsensusTrimPercent:     trimPercent,
+			ConsensusMinQuotes:       minQuotes,
+		},
 		AuthConfig: AuthConfig{
-			Secret:    getEnv("AUTH_SECRET", ""),
-			ExpiryMin: expiryMin,
+			KeysDir:       getEnv("AUTH_KEYS_DIR", ""),
+			SigningKeyID:  getEnv("AUTH_SIGNING_KEY_ID", ""),
+			ExpiryMin:     expiryMin,
+			RefreshExpiry: time.Duration(refreshExpiryHours) * time.Hour,
+		},
+		MoneyConfig: MoneyConfig{
+			RoundingMode:  roundingMode,
+			RoundingScale: roundingScale,
+		},
+		RateGuard:      rateGuard,
+		SeedCurrencies: seedCurrencies,
+		SyncConfig: SyncConfig{
+			Schedule:         getEnv("SYNC_SCHEDULE", ""),
+			BaseCurrencies:   getEnvList("SYNC_BASE_CURRENCIES", "USD"),
+			TargetCurrencies: getEnvList("SYNC_TARGET_CURRENCIES", ""),
+			Jitter:           time.Duration(jitterSec) * time.Second,
 		},
 	}
 
@@ -50,16 +221,112 @@ func LoadConfig() (Config, error) {
 		return Config{}, fmt.Errorf("DB_URL must be set")
 	}
 
-	if cfg.AuthConfig.Secret == "" {
-		return Config{}, fmt.Errorf("AUTH_SECRET must be set")
+	if cfg.AuthConfig.KeysDir == "" {
+		return Config{}, fmt.Errorf("AUTH_KEYS_DIR must be set")
+	}
+
+	if cfg.ProviderConfig.Mode != SyncModeFailover && cfg.ProviderConfig.Mode != SyncModeConsensus {
+		return Config{}, fmt.Errorf("invalid SYNC_MODE: %q", cfg.ProviderConfig.Mode)
+	}
+	if cfg.ProviderConfig.ConsensusMethod != "median" && cfg.ProviderConfig.ConsensusMethod != "trimmed-mean" {
+		return Config{}, fmt.Errorf("invalid CONSENSUS_METHOD: %q", cfg.ProviderConfig.ConsensusMethod)
+	}
+	if len(cfg.ProviderConfig.Names) == 0 {
+		return Config{}, fmt.Errorf("RATE_PROVIDERS must be set")
 	}
-	if cfg.ExchangeRateAPI == "" {
-		return Config{}, fmt.Errorf("EXCHANGE_RATE_API must be set")
+	for _, name := range cfg.ProviderConfig.Names {
+		switch name {
+		case ProviderExchangeRateAPI:
+			if cfg.ProviderConfig.ExchangeRateAPI == "" {
+				return Config{}, fmt.Errorf("EXCHANGE_RATE_API must be set")
+			}
+		case ProviderECB:
+		case ProviderFile:
+			if cfg.ProviderConfig.FilePath == "" {
+				return Config{}, fmt.Errorf("RATE_FILE_PATH must be set")
+			}
+		default:
+			return Config{}, fmt.Errorf("invalid RATE_PROVIDERS entry: %q", name)
+		}
 	}
 
 	return cfg, nil
 }
 
+// parseRateGuard reads the default max move and the per pair limits, e.g.
+// RATE_GUARD_PAIRS="USD/INR:max_move_pct=5,min=70,max=100;EUR/USD:max_move_pct=2"
+// an empty default max move disables the move check for pairs without a limit
+func parseRateGuard(defaultMaxMove, pairs string) (RateGuardConfig, error) {
+	guard := RateGuardConfig{Pairs: map[string]PairLimit{}}
+
+	if defaultMaxMove != "" {
+		v, err := decimal.Parse(defaultMaxMove)
+		if err != nil || v.Sign() <= 0 {
+			return RateGuardConfig{}, fmt.Errorf("invalid RATE_GUARD_MAX_MOVE_PCT: %q", defaultMaxMove)
+		}
+		guard.DefaultMaxMovePct = &v
+	}
+
+	for _, entry := range strings.Split(pairs, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pair, options, _ := strings.Cut(entry, ":")
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		if len(pair) != 7 || pair[3] != '/' {
+			return RateGuardConfig{}, fmt.Errorf("invalid RATE_GUARD_PAIRS pair: %q", pair)
+		}
+
+		var limit PairLimit
+		for _, option := range strings.Split(options, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(option), "=")
+			if !ok {
+				return RateGuardConfig{}, fmt.Errorf("invalid RATE_GUARD_PAIRS option for %s: %q", pair, option)
+			}
+			v, err := decimal.Parse(value)
+			if err != nil || v.Sign() <= 0 {
+				return RateGuardConfig{}, fmt.Errorf("invalid RATE_GUARD_PAIRS value for %s: %q", pair, option)
+			}
+			switch key {
+			case "max_move_pct":
+				limit.MaxMovePct = &v
+			case "min":
+				limit.MinRate = &v
+			case "max":
+				limit.MaxRate = &v
+			default:
+				return RateGuardConfig{}, fmt.Errorf("unknown RATE_GUARD_PAIRS option for %s: %q", pair, key)
+			}
+		}
+		guard.Pairs[pair] = limit
+	}
+
+	return guard, nil
+}
+
+// getEnvList reads a comma separated list of currency codes
+func getEnvList(key, defaultVal string) []string {
+	var list []string
+	for _, v := range strings.Split(getEnv(key, defaultVal), ",") {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// getEnvNames reads a comma separated list of lower case names
+func getEnvNames(key, defaultVal string) []string {
+	var list []string
+	for _, v := range strings.Split(getEnv(key, defaultVal), ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
 func getEnv(key, defaultVal string) string {
 	if v, ok := os.LookupEnv(key); ok {
 		return v
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/middleware"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID int, name string, scopes []string, expiresInDays *int) (*models.APIKey, string, *utils.AppError)
+	GetAPIKeys(ctx context.Context, userID int) ([]models.APIKey, *utils.AppError)
+	RevokeAPIKey(ctx context.Context, id int, userID int, role string) *utils.AppError
+}
+
+type APIKeyController struct {
+	apiKeyService APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService APIKeyService) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyController) CreateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	var req dto.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	key, fullKey, appErr := h.apiKeyService.CreateAPIKey(ctx, claims.UserID, req.Name, req.Scopes, req.ExpiresInDays)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created, store it now as it will not be shown again",
+		"key":     fullKey,
+		"api_key": toAPIKeyResponse(*key),
+	})
+}
+
+func (h *APIKeyController) GetAPIKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	result, appErr := h.apiKeyService.GetAPIKeys(ctx, claims.UserID)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	keys := make([]dto.APIKeyResponse, 0, len(result))
+	for _, key := range result {
+		keys = append(keys, toAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "API keys fetched successfully",
+		"api_keys": keys,
+	})
+}
+
+func (h *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	id, err := utils.ParseIDParam("id", c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID param",
+		})
+		return
+	}
+
+	if appErr := h.apiKeyService.RevokeAPIKey(ctx, id, claims.UserID, claims.Role); appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"message": "API key revoked successfully",
+	})
+}
+
+func toAPIKeyResponse(key models.APIKey) dto.APIKeyResponse {
+	resp := dto.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt.Format(time.RFC3339),
+	}
+	if resp.Scopes == nil {
+		resp.Scopes = []string{}
+	}
+	if key.ExpiresAt != nil {
+		resp.ExpiresAt = key.ExpiresAt.Format(time.RFC3339)
+	}
+	if key.LastUsedAt != nil {
+		resp.LastUsedAt = key.LastUsedAt.Format(time.RFC3339)
+	}
+	if key.RevokedAt != nil {
+		resp.RevokedAt = key.RevokedAt.Format(time.RFC3339)
+	}
+	return resp
+}
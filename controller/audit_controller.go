@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+type AuditService interface {
+	GetAuditEvents(ctx context.Context, filter dto.AuditFilter) ([]models.AuditEvent, int64, *utils.AppError)
+}
+
+type AuditController struct {
+	auditService AuditService
+}
+
+func NewAuditController(auditService AuditService) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+	}
+}
+
+// GetAuditEvents lists audit events, filterable by entity, entity_id, actor_id, action and a from/to range
+func (h *AuditController) GetAuditEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	result, total, appErr := h.auditService.GetAuditEvents(ctx, filter)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	events := make([]dto.AuditEventResponse, 0, len(result))
+	for _, event := range result {
+		events = append(events, dto.AuditEventResponse{
+			ID:        event.ID,
+			ActorID:   event.ActorID,
+			Action:    event.Action,
+			Entity:    event.Entity,
+			EntityID:  event.EntityID,
+			Before:    event.Before,
+			After:     event.After,
+			Changes:   event.Changes,
+			RequestID: event.RequestID,
+			CreatedAt: event.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Audit events fetched successfully",
+		"events":    events,
+		"page":      filter.Page,
+		"page_size": filter.PageSize,
+		"total":     total,
+	})
+}
+
+func parseAuditFilter(c *gin.Context) (dto.AuditFilter, error) {
+	filter := dto.AuditFilter{
+		Entity:   c.Query("entity"),
+		Action:   c.Query("action"),
+		Page:     1,
+		PageSize: defaultAuditPageSize,
+	}
+
+	switch filter.Entity {
+	case "", models.AuditEntityCurrency, models.AuditEntityExchangeRate, models.AuditEntityQuarantinedRate:
+	default:
+		return dto.AuditFilter{}, errInvalidQuery("entity, expected currency, exchange_rate or quarantined_rate")
+	}
+	switch filter.Action {
+	case "", models.AuditCreate, models.AuditUpdate, models.AuditDelete, models.AuditSync, models.AuditRestore, models.AuditApprove, models.AuditReject:
+	default:
+		return dto.AuditFilter{}, errInvalidQuery("action, expected create, update, delete, sync, restore, approve or reject")
+	}
+
+	if v := c.Query("entity_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return dto.AuditFilter{}, errInvalidQuery("entity_id")
+		}
+		filter.EntityID = &id
+	}
+	if v := c.Query("actor_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return dto.AuditFilter{}, errInvalidQuery("actor_id")
+		}
+		filter.ActorID = &id
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := utils.ParseRangeStart(v)
+		if err != nil {
+			return dto.AuditFilter{}, errInvalidQuery("from, " + err.Error())
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := utils.ParsePointInTime(v)
+		if err != nil {
+			return dto.AuditFilter{}, errInvalidQuery("to, " + err.Error())
+		}
+		filter.To = &to
+	}
+	if v := c.Query("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return dto.AuditFilter{}, errInvalidQuery("page")
+		}
+		filter.Page = page
+	}
+	if v := c.Query("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 1 || size > maxAuditPageSize {
+			return dto.AuditFilter{}, errInvalidQuery("page_size, expected 1 to " + strconv.Itoa(maxAuditPageSize))
+		}
+		filter.PageSize = size
+	}
+	return filter, nil
+}
+
+func errInvalidQuery(param string) error {
+	return errors.New("Invalid " + param)
+}
@@ -6,12 +6,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"currency-converter/dto"
+	"currency-converter/middleware"
+	"currency-converter/security"
 	"currency-converter/utils"
 )
 
 type UserService interface {
 	Login(context.Context, dto.LoginRequest) (dto.LoginResult, *utils.AppError)
 	Register(context.Context, dto.RegisterRequest) (int, *utils.AppError)
+	UpdateRole(context.Context, int, string) *utils.AppError
+	Refresh(context.Context, string) (dto.LoginResult, *utils.AppError)
+	Logout(context.Context, *security.RequestClaims) *utils.AppError
 }
 
 type UserController struct {
@@ -70,10 +75,99 @@ func (h *UserController) Login(c *gin.Context) {
 	}
 
 	resp := dto.LoginResponse{
-		UserId:  result.ID,
-		Token:   result.Token,
-		Message: "User Logged in Successfully",
+		UserId:       result.ID,
+		Token:        result.Token,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    result.ExpiresIn,
+		Message:      "User Logged in Successfully",
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token
+func (h *UserController) Refresh(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	result, err := h.userService.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		c.JSON(err.Code, gin.H{
+			"error": err.Message,
+		})
+		return
+	}
+
+	resp := dto.LoginResponse{
+		UserId:       result.ID,
+		Token:        result.Token,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    result.ExpiresIn,
+		Message:      "Token refreshed successfully",
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *UserController) Logout(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	if err := h.userService.Logout(ctx, claims); err != nil {
+		c.JSON(err.Code, gin.H{
+			"error": err.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User logged out successfully",
+	})
+}
+
+func (h *UserController) UpdateRole(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := utils.ParseIDParam("id", c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID param",
+		})
+		return
+	}
+
+	var req dto.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if appErr := h.userService.UpdateRole(ctx, id, req.Role); appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": id,
+		"role":    req.Role,
+		"message": "User role updated successfully",
+	})
+}
@@ -2,17 +2,20 @@ package controller
 
 import (
 	"context"
+	"currency-converter/decimal"
 	"currency-converter/dto"
 	"currency-converter/utils"
 	"net/http"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ConversionService interface {
 	ConvertCurrency(ctx context.Context, cmd dto.ConversionCmd) (dto.ConversionResult, *utils.AppError)
+	ConvertBatch(ctx context.Context, items []dto.ConversionCmd, at *time.Time) ([]dto.BatchConversionResult, *utils.AppError)
+	ConvertToMany(ctx context.Context, from string, amount decimal.Decimal, targets []string, at *time.Time) (dto.MultiConversionResult, *utils.AppError)
 }
 
 type ConversionController struct {
@@ -50,7 +53,7 @@ func (h *ConversionController) ConvertCurrency(c *gin.Context) {
 		return
 	}
 
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	amount, err := decimal.Parse(amountStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid amount",
@@ -58,18 +61,32 @@ func (h *ConversionController) ConvertCurrency(c *gin.Context) {
 		return
 	}
 
-	if amount <= 0 {
+	if amount.Sign() <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Amount must be greater than zero",
 		})
 		return
 	}
 
-	result, appError := h.conversionService.ConvertCurrency(ctx, dto.ConversionCmd{
+	cmd := dto.ConversionCmd{
 		From:   from,
 		To:     to,
 		Amount: amount,
-	})
+	}
+
+	// optional point in time, e.g. at=2024-01-31 or at=2024-01-31T18:00:00Z
+	if atStr := c.Query("at"); atStr != "" {
+		at, err := utils.ParsePointInTime(atStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid at: " + err.Error(),
+			})
+			return
+		}
+		cmd.At = &at
+	}
+
+	result, appError := h.conversionService.ConvertCurrency(ctx, cmd)
 	if appError != nil {
 		c.JSON(appError.Code, gin.H{
 			"error": appError.Message,
@@ -77,11 +94,202 @@ func (h *ConversionController) ConvertCurrency(c *gin.Context) {
 		return
 	}
 	resp := dto.CurrencyConversionResponse{
-		From:            from,
-		To:              to,
-		Amount:          amount,
-		Rate:            result.Rate,
-		ConvertedAmount: result.ConvertedAmount,
+		From:               from,
+		To:                 to,
+		Amount:             amount,
+		Rate:               result.Rate,
+		ConvertedAmount:    result.ConvertedAmount,
+		RawConvertedAmount: result.RawConvertedAmount,
+		CashAmount:         result.CashAmount,
+		MinorUnits:         result.MinorUnits,
+		RoundingMode:       string(result.RoundingMode),
+		Legs:               result.Legs,
+	}
+	if cmd.At != nil {
+		resp.At = cmd.At.Format(time.RFC3339)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ConvertBatch converts many items in one call, an invalid item or a missing rate
+// only fails that item
+func (h *ConversionController) ConvertBatch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.BatchConversionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request, expected 1 to 1000 items with from, to and amount",
+		})
+		return
+	}
+
+	var at *time.Time
+	if req.At != "" {
+		t, err := utils.ParsePointInTime(req.At)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid at: " + err.Error(),
+			})
+			return
+		}
+		at = &t
+	}
+
+	items := make([]dto.BatchConversionItemResponse, len(req.Items))
+	var cmds []dto.ConversionCmd
+	var cmdIndexes []int
+	for i, item := range req.Items {
+		from := strings.ToUpper(item.From)
+		to := strings.ToUpper(item.To)
+		items[i] = dto.BatchConversionItemResponse{
+			Index:  i,
+			From:   from,
+			To:     to,
+			Amount: item.Amount,
+		}
+
+		switch {
+		case from == to:
+			items[i].Error = &dto.ItemError{Code: http.StatusBadRequest, Message: "From and To currencies cannot be the same"}
+		case item.Amount.Sign() <= 0:
+			items[i].Error = &dto.ItemError{Code: http.StatusBadRequest, Message: "Amount must be greater than zero"}
+		default:
+			cmds = append(cmds, dto.ConversionCmd{From: from, To: to, Amount: item.Amount, At: at})
+			cmdIndexes = append(cmdIndexes, i)
+		}
+	}
+
+	var roundingMode string
+	if len(cmds) > 0 {
+		results, appErr := h.conversionService.ConvertBatch(ctx, cmds, at)
+		if appErr != nil {
+			c.JSON(appErr.Code, gin.H{
+				"error": appErr.Message,
+			})
+			return
+		}
+
+		for j, result := range results {
+			item := &items[cmdIndexes[j]]
+			if result.Err != nil {
+				item.Error = &dto.ItemError{Code: result.Err.Code, Message: result.Err.Message}
+				continue
+			}
+			item.Rate = &result.Result.Rate
+			item.ConvertedAmount = &result.Result.ConvertedAmount
+			item.RawConvertedAmount = &result.Result.RawConvertedAmount
+			item.CashAmount = result.Result.CashAmount
+			item.MinorUnits = &result.Result.MinorUnits
+			item.Legs = result.Result.Legs
+			roundingMode = string(result.Result.RoundingMode)
+		}
+	}
+
+	failed := 0
+	for _, item := range items {
+		if item.Error != nil {
+			failed++
+		}
+	}
+
+	resp := gin.H{
+		"items":     items,
+		"succeeded": len(items) - failed,
+		"failed":    failed,
+	}
+	if roundingMode != "" {
+		resp["rounding_mode"] = roundingMode
+	}
+	if at != nil {
+		resp["at"] = at.Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ConvertToMany converts one amount into a list of targets, to=* means every active currency
+func (h *ConversionController) ConvertToMany(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	from := strings.ToUpper(c.Query("from"))
+	to := c.Query("to")
+	amountStr := c.Query("amount")
+
+	if from == "" || to == "" || amountStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing required query parameters: from, to, amount",
+		})
+		return
+	}
+
+	amount, err := decimal.Parse(amountStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid amount",
+		})
+		return
+	}
+
+	if amount.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Amount must be greater than zero",
+		})
+		return
+	}
+
+	// nil targets converts into every active currency
+	var targets []string
+	if to != "*" {
+		seen := map[string]bool{}
+		for _, code := range strings.Split(to, ",") {
+			code = strings.ToUpper(strings.TrimSpace(code))
+			if code == "" || seen[code] {
+				continue
+			}
+			seen[code] = true
+			targets = append(targets, code)
+		}
+		if len(targets) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "to must be a comma separated list of currency codes or *",
+			})
+			return
+		}
+	}
+
+	var at *time.Time
+	if atStr := c.Query("at"); atStr != "" {
+		t, err := utils.ParsePointInTime(atStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid at: " + err.Error(),
+			})
+			return
+		}
+		at = &t
+	}
+
+	result, appErr := h.conversionService.ConvertToMany(ctx, from, amount, targets, at)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	resp := dto.MultiConversionResponse{
+		From:         from,
+		Amount:       amount,
+		RoundingMode: string(result.RoundingMode),
+		Conversions:  result.Conversions,
+		Missing:      result.Missing,
+	}
+	if resp.Conversions == nil {
+		resp.Conversions = []dto.TargetConversion{}
+	}
+	if at != nil {
+		resp.At = at.Format(time.RFC3339)
 	}
 
 	c.JSON(http.StatusOK, resp)
@@ -6,6 +6,7 @@ import (
 	"currency-converter/models"
 	"currency-converter/utils"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,9 +15,10 @@ import (
 type CurrencyService interface {
 	CreateCurrency(ctx context.Context, req dto.CurrencyRequest) (*models.Currency, *utils.AppError)
 	GetCurrencyByID(ctx context.Context, id int) (*models.Currency, *utils.AppError)
-	GetAllCurrencies(ctx context.Context) ([]models.Currency, *utils.AppError)
-	UpdateCurrency(ctx context.Context, id int, req dto.CurrencyUpdateRequest) *utils.AppError
-	DeleteCurrency(ctx context.Context, id int) *utils.AppError
+	ListCurrencies(ctx context.Context, filter dto.CurrencyFilter) ([]models.Currency, int64, string, *utils.AppError)
+	UpdateCurrency(ctx context.Context, id int, req dto.CurrencyUpdateRequest) (int64, *utils.AppError)
+	DeleteCurrency(ctx context.Context, id int) (int64, *utils.AppError)
+	RestoreCurrency(ctx context.Context, id int, restoreRates bool) (*models.Currency, int64, *utils.AppError)
 }
 
 type CurrencyController struct {
@@ -45,17 +47,7 @@ func (h *CurrencyController) CreateCurrency(c *gin.Context) {
 		})
 		return
 	}
-	resp := dto.CurrencyResponse{
-		ID:        result.ID,
-		Code:      result.Code,
-		Name:      result.Name,
-		Symbol:    result.Symbol,
-		IsActive:  result.IsActive,
-		Deleted:   result.Deleted,
-		DeletedAt: result.DeletedAt.Format(time.RFC3339),
-		UpdatedAt: result.UpdatedAt.Format(time.RFC3339),
-		CreatedAt: result.CreatedAt.Format(time.RFC3339),
-	}
+	resp := toCurrencyResponse(*result)
 
 	c.JSON(http.StatusCreated, resp)
 }
@@ -79,49 +71,41 @@ func (h *CurrencyController) GetCurrencyByID(c *gin.Context) {
 		return
 	}
 
-	resp := dto.CurrencyResponse{
-		ID:        result.ID,
-		Code:      result.Code,
-		Name:      result.Name,
-		Symbol:    result.Symbol,
-		IsActive:  result.IsActive,
-		Deleted:   result.Deleted,
-		DeletedAt: result.DeletedAt.Format(time.RFC3339),
-		UpdatedAt: result.UpdatedAt.Format(time.RFC3339),
-		CreatedAt: result.CreatedAt.Format(time.RFC3339),
-	}
+	resp := toCurrencyResponse(*result)
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// GetCurrencies lists currencies a page at a time, filterable by is_active, a code prefix and a name search
 func (h *CurrencyController) GetCurrencies(c *gin.Context) {
 	ctx := c.Request.Context()
-	result, err := h.currencyService.GetAllCurrencies(ctx)
+
+	filter, err := parseCurrencyFilter(c)
 	if err != nil {
-		c.JSON(err.Code, gin.H{
-			"error": err.Message,
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	result, total, next, appErr := h.currencyService.ListCurrencies(ctx, filter)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
 		})
 		return
 	}
 
 	currencies := make([]dto.CurrencyResponse, 0, len(result))
 	for _, currency := range result {
-		resp := dto.CurrencyResponse{
-			ID:        currency.ID,
-			Code:      currency.Code,
-			Name:      currency.Name,
-			Symbol:    currency.Symbol,
-			IsActive:  currency.IsActive,
-			Deleted:   currency.Deleted,
-			DeletedAt: currency.DeletedAt.Format(time.RFC3339),
-			UpdatedAt: currency.UpdatedAt.Format(time.RFC3339),
-			CreatedAt: currency.CreatedAt.Format(time.RFC3339),
-		}
+		resp := toCurrencyResponse(currency)
 		currencies = append(currencies, resp)
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Currencies fetched successfully",
-		"currencies": currencies,
+		"message":     "Currencies fetched successfully",
+		"currencies":  currencies,
+		"total":       total,
+		"next_cursor": nextCursorOrNil(next),
 	})
 }
 
@@ -143,7 +127,7 @@ func (h *CurrencyController) UpdateCurrency(c *gin.Context) {
 		return
 	}
 
-	apperr := h.currencyService.UpdateCurrency(ctx, id, req)
+	ratesCascaded, apperr := h.currencyService.UpdateCurrency(ctx, id, req)
 	if apperr != nil {
 		c.JSON(apperr.Code, gin.H{
 			"error": apperr.Message,
@@ -151,9 +135,18 @@ func (h *CurrencyController) UpdateCurrency(c *gin.Context) {
 		return
 	}
 
+	// {"is_active": true, "restore_rates": true} reactivates the rates deactivated with the currency
+	var ratesDeactivated, ratesReactivated int64
+	if req.IsActive != nil && *req.IsActive {
+		ratesReactivated = ratesCascaded
+	} else {
+		ratesDeactivated = ratesCascaded
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"id": id,
-		"message": "Currency updated successfully",
+		"id":                id,
+		"message":           "Currency updated successfully",
+		"rates_deactivated": ratesDeactivated,
+		"rates_reactivated": ratesReactivated,
 	})
 }
 
@@ -166,7 +159,7 @@ func (h *CurrencyController) DeleteCurrency(c *gin.Context) {
 		})
 		return
 	}
-	apperr := h.currencyService.DeleteCurrency(ctx, id)
+	ratesDeleted, apperr := h.currencyService.DeleteCurrency(ctx, id)
 	if apperr != nil {
 		c.JSON(apperr.Code, gin.H{
 			"error": apperr.Message,
@@ -174,7 +167,85 @@ func (h *CurrencyController) DeleteCurrency(c *gin.Context) {
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Currency deleted successfully",
-		"id":      id,
+		"message":       "Currency deleted successfully",
+		"id":            id,
+		"rates_deleted": ratesDeleted,
 	})
 }
+
+// RestoreCurrency undeletes a currency, {"restore_rates": true} also restores the rates deleted with it
+func (h *CurrencyController) RestoreCurrency(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := utils.ParseIDParam("id", c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID param",
+		})
+		return
+	}
+
+	// the body is optional, without one only the currency is restored
+	var req dto.CurrencyRestoreRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request",
+			})
+			return
+		}
+	}
+
+	currency, ratesRestored, apperr := h.currencyService.RestoreCurrency(ctx, id, req.RestoreRates)
+	if apperr != nil {
+		c.JSON(apperr.Code, gin.H{
+			"error": apperr.Message,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Currency restored successfully",
+		"currency":       toCurrencyResponse(*currency),
+		"rates_restored": ratesRestored,
+	})
+}
+
+func toCurrencyResponse(currency models.Currency) dto.CurrencyResponse {
+	return dto.CurrencyResponse{
+		ID:                    currency.ID,
+		Code:                  currency.Code,
+		Name:                  currency.Name,
+		Symbol:                currency.Symbol,
+		NumericCode:           currency.NumericCode,
+		MinorUnits:            currency.MinorUnits,
+		CashRoundingIncrement: currency.CashRoundingIncrement,
+		IsActive:              currency.IsActive,
+		Deleted:               currency.Deleted,
+		DeletedAt:             currency.DeletedAt.Format(time.RFC3339),
+		UpdatedAt:             currency.UpdatedAt.Format(time.RFC3339),
+		CreatedAt:             currency.CreatedAt.Format(time.RFC3339),
+		CreatedBy:             currency.CreatedBy,
+		UpdatedBy:             currency.UpdatedBy,
+	}
+}
+
+func parseCurrencyFilter(c *gin.Context) (dto.CurrencyFilter, error) {
+	params, err := parseListParams(c, dto.CurrencySortFields, "code")
+	if err != nil {
+		return dto.CurrencyFilter{}, err
+	}
+	filter := dto.CurrencyFilter{
+		CodePrefix: strings.ToUpper(c.Query("code")),
+		Name:       c.Query("name"),
+		Sort:       params.Sort,
+		Cursor:     params.Cursor,
+		Limit:      params.Limit,
+	}
+
+	if len(filter.CodePrefix) > 3 || strings.Trim(filter.CodePrefix, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return dto.CurrencyFilter{}, errInvalidQuery("code, expected up to 3 letters")
+	}
+	if filter.IsActive, err = parseBoolQuery(c, "is_active"); err != nil {
+		return dto.CurrencyFilter{}, err
+	}
+	return filter, nil
+}
@@ -0,0 +1,316 @@
+package controller
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/middleware"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeRateChangeService interface {
+	ProposeChange(ctx context.Context, exchangeRateID int, proposerID int, req dto.ExchangeRateUpdateRequest) (*models.ExchangeRateChange, *utils.AppError)
+	ProposeChangeByPair(ctx context.Context, fromCode string, toCode string, proposerID int, req dto.ExchangeRateUpdateRequest) (*models.ExchangeRateChange, *utils.AppError)
+	ProposeCreate(ctx context.Context, proposerID int, req dto.ExchangeRateRequest) (*models.ExchangeRateChange, *utils.AppError)
+	ProposeDelete(ctx context.Context, exchangeRateID int, proposerID int) (*models.ExchangeRateChange, *utils.AppError)
+	GetChanges(ctx context.Context, status string) ([]models.ExchangeRateChange, *utils.AppError)
+	ApproveChange(ctx context.Context, id int, reviewerID int) (*models.ExchangeRateChange, *utils.AppError)
+	RejectChange(ctx context.Context, id int, reviewerID int) *utils.AppError
+}
+
+type ExchangeRateChangeController struct {
+	changeService ExchangeRateChangeService
+}
+
+func NewExchangeRateChangeController(changeService ExchangeRateChangeService) *ExchangeRateChangeController {
+	return &ExchangeRateChangeController{
+		changeService: changeService,
+	}
+}
+
+// ProposeChange records a manual rate change, it takes effect once another user approves it
+func (h *ExchangeRateChangeController) ProposeChange(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	id, err := utils.ParseIDParam("id", c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID param",
+		})
+		return
+	}
+
+	req, ok := bindExchangeRateUpdate(c)
+	if !ok {
+		return
+	}
+
+	change, appErr := h.changeService.ProposeChange(ctx, id, claims.UserID, req)
+	h.respondProposed(c, change, appErr)
+}
+
+// ProposeChangeByPair is ProposeChange for a rate named by its currency codes, /exchange-rates/pair/USD/INR
+func (h *ExchangeRateChangeController) ProposeChangeByPair(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	from, to, ok := parsePairParams(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindExchangeRateUpdate(c)
+	if !ok {
+		return
+	}
+
+	change, appErr := h.changeService.ProposeChangeByPair(ctx, from, to, claims.UserID, req)
+	h.respondProposed(c, change, appErr)
+}
+
+// ProposeCreate records a new exchange rate, it is created once another user approves it
+func (h *ExchangeRateChangeController) ProposeCreate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	var req dto.ExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	if req.Rate.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Rate is required",
+		})
+		return
+	}
+	if req.FromCurrency == "" && req.FromCurrencyID == 0 || req.ToCurrency == "" && req.ToCurrencyID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from_currency and to_currency (or their ids) are required",
+		})
+		return
+	}
+
+	change, appErr := h.changeService.ProposeCreate(ctx, claims.UserID, req)
+	h.respondProposed(c, change, appErr)
+}
+
+// ProposeDelete records the deletion of an exchange rate, it is deleted once another user approves it
+func (h *ExchangeRateChangeController) ProposeDelete(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	id, err := utils.ParseIDParam("id", c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID param",
+		})
+		return
+	}
+
+	change, appErr := h.changeService.ProposeDelete(ctx, id, claims.UserID)
+	h.respondProposed(c, change, appErr)
+}
+
+func (*ExchangeRateChangeController) respondProposed(c *gin.Context, change *models.ExchangeRateChange, appErr *utils.AppError) {
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Exchange rate change proposed, it needs approval by another user",
+		"change":  toExchangeRateChangeResponse(*change),
+	})
+}
+
+// bindExchangeRateUpdate reads and checks the body of a rate change, writing the 400 itself
+func bindExchangeRateUpdate(c *gin.Context) (dto.ExchangeRateUpdateRequest, bool) {
+	var req dto.ExchangeRateUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return req, false
+	}
+	if req.Rate == nil && req.IsActive == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "At least one field (rate or is_active) must be provided for update",
+		})
+		return req, false
+	}
+	if req.Rate != nil && req.Rate.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Rate must be greater than zero",
+		})
+		return req, false
+	}
+	return req, true
+}
+
+func (h *ExchangeRateChangeController) GetChanges(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// pending by default, status=all lists every review state
+	status := c.DefaultQuery("status", models.ChangePending)
+	switch status {
+	case "all":
+		status = ""
+	case models.ChangePending, models.ChangeApproved, models.ChangeRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status, expected pending, approved, rejected or all",
+		})
+		return
+	}
+
+	result, appErr := h.changeService.GetChanges(ctx, status)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	changes := make([]dto.ExchangeRateChangeResponse, 0, len(result))
+	for _, change := range result {
+		changes = append(changes, toExchangeRateChangeResponse(change))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Exchange rate changes fetched successfully",
+		"changes": changes,
+	})
+}
+
+func (h *ExchangeRateChangeController) ApproveChange(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	id, err := utils.ParseIDParam("id", c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID param",
+		})
+		return
+	}
+
+	change, appErr := h.changeService.ApproveChange(ctx, id, claims.UserID)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	// an approved rate can still trip the rate guard and wait in quarantine
+	if change.QuarantineID != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Exchange rate change approved but quarantined for review",
+			"change":  toExchangeRateChangeResponse(*change),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Exchange rate change approved and applied",
+		"change":  toExchangeRateChangeResponse(*change),
+	})
+}
+
+func (h *ExchangeRateChangeController) RejectChange(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	id, err := utils.ParseIDParam("id", c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID param",
+		})
+		return
+	}
+
+	appErr := h.changeService.RejectChange(ctx, id, claims.UserID)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"message": "Exchange rate change rejected",
+	})
+}
+
+func toExchangeRateChangeResponse(change models.ExchangeRateChange) dto.ExchangeRateChangeResponse {
+	resp := dto.ExchangeRateChangeResponse{
+		ID:             change.ID,
+		Action:         change.Action,
+		ExchangeRateID: change.ExchangeRateID,
+		FromCurrencyID: change.FromCurrencyID,
+		ToCurrencyID:   change.ToCurrencyID,
+		Rate:           change.Rate,
+		IsActive:       change.IsActive,
+		Status:         change.Status,
+		ProposedBy:     change.ProposedBy,
+		ReviewedBy:     change.ReviewedBy,
+		QuarantineID:   change.QuarantineID,
+		CreatedAt:      change.CreatedAt.Format(time.RFC3339),
+	}
+	if change.ReviewedAt != nil {
+		resp.ReviewedAt = change.ReviewedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -13,12 +13,9 @@ import (
 )
 
 type ExchangeRateService interface {
-	CreateExchangeRate(ctx context.Context, req dto.ExchangeRateRequest) (*models.ExchangeRate, *utils.AppError)
 	GetExchangeRateByID(ctx context.Context, id int) (*models.ExchangeRate, *utils.AppError)
-	GetAllExchangeRates(ctx context.Context) ([]models.ExchangeRate, *utils.AppError)
-	UpdateExchangeRate(ctx context.Context, id int, req dto.ExchangeRateUpdateRequest) *utils.AppError
-	DeleteExchangeRate(ctx context.Context, id int) *utils.AppError
-	SyncExchangeRates(ctx context.Context, code string) *utils.AppError
+	GetExchangeRateByPair(ctx context.Context, fromCode string, toCode string) (*models.ExchangeRate, *utils.AppError)
+	ListExchangeRates(ctx context.Context, filter dto.ExchangeRateFilter) ([]models.ExchangeRate, int64, string, *utils.AppError)
 }
 
 type ExchangeRateController struct {
@@ -31,45 +28,6 @@ func NewExchangeRateController(exchangeRateService ExchangeRateService) *Exchang
 	}
 }
 
-func (h *ExchangeRateController) CreateExchangeRate(c *gin.Context) {
-	ctx := c.Request.Context()
-
-	var req dto.ExchangeRateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request",
-		})
-		return
-	}
-	if req.ToCurrencyID == req.FromCurrencyID {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "FromCurrencyID and ToCurrencyID cannot be the same",
-		})
-		return
-	}
-
-	exchangeRate, err := h.exchangeRateService.CreateExchangeRate(ctx, req)
-	if err != nil {
-		c.JSON(err.Code, gin.H{
-			"error": err.Message,
-		})
-		return
-	}
-	resp := dto.ExchangeRateResponse{
-		ID:             exchangeRate.ID,
-		FromCurrencyID: exchangeRate.FromCurrencyID,
-		ToCurrencyID:   exchangeRate.ToCurrencyID,
-		Rate:           exchangeRate.Rate,
-		IsActive:       exchangeRate.IsActive,
-		Deleted:        exchangeRate.Deleted,
-		DeletedAt:      exchangeRate.DeletedAt.Format(time.RFC3339),
-		UpdatedAt:      exchangeRate.UpdatedAt.Format(time.RFC3339),
-		CreatedAt:      exchangeRate.CreatedAt.Format(time.RFC3339),
-	}
-
-	c.JSON(http.StatusCreated, resp)
-}
-
 func (h *ExchangeRateController) GetExchangeRateByID(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -89,142 +47,148 @@ func (h *ExchangeRateController) GetExchangeRateByID(c *gin.Context) {
 		return
 	}
 
-	resp := dto.ExchangeRateResponse{
-		ID:             exchangeRate.ID,
-		FromCurrencyID: exchangeRate.FromCurrencyID,
-		ToCurrencyID:   exchangeRate.ToCurrencyID,
-		Rate:           exchangeRate.Rate,
-		IsActive:       exchangeRate.IsActive,
-		Deleted:        exchangeRate.Deleted,
-		DeletedAt:      exchangeRate.DeletedAt.Format(time.RFC3339),
-		CreatedAt:      exchangeRate.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:      exchangeRate.UpdatedAt.Format(time.RFC3339),
-	}
+	resp := toExchangeRateResponse(*exchangeRate)
 
 	c.JSON(http.StatusOK, resp)
 }
 
-func (h *ExchangeRateController) GetAllExchangeRates(c *gin.Context) {
+// GetExchangeRateByPair finds a rate by its currency codes, /exchange-rates/pair/USD/INR
+func (h *ExchangeRateController) GetExchangeRateByPair(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	result, appErr := h.exchangeRateService.GetAllExchangeRates(ctx)
+	from, to, ok := parsePairParams(c)
+	if !ok {
+		return
+	}
+
+	exchangeRate, appErr := h.exchangeRateService.GetExchangeRateByPair(ctx, from, to)
 	if appErr != nil {
 		c.JSON(appErr.Code, gin.H{
 			"error": appErr.Message,
 		})
 		return
 	}
-	exchangeRates := make([]dto.ExchangeRateResponse, 0, len(result))
-	for _, rate := range result {
-		exchangeRates = append(exchangeRates, dto.ExchangeRateResponse{
-			ID:             rate.ID,
-			FromCurrencyID: rate.FromCurrencyID,
-			ToCurrencyID:   rate.ToCurrencyID,
-			Rate:           rate.Rate,
-			IsActive:       rate.IsActive,
-			Deleted:        rate.Deleted,
-			DeletedAt:      rate.DeletedAt.Format(time.RFC3339),
-			CreatedAt:      rate.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:      rate.UpdatedAt.Format(time.RFC3339),
-		})
-	}
-	c.JSON(http.StatusOK, gin.H{
-		"message":        "Exchange rates fetched successfully",
-		"exchange_rates": exchangeRates,
-	})
+
+	c.JSON(http.StatusOK, toExchangeRateResponse(*exchangeRate))
 }
 
-func (h *ExchangeRateController) UpdateExchangeRate(c *gin.Context) {
+// GetAllExchangeRates lists exchange rates a page at a time, filterable by from/to code, is_active and updated_since
+func (h *ExchangeRateController) GetAllExchangeRates(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	id, err := utils.ParseIDParam("id", c)
+	filter, err := parseExchangeRateFilter(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid ID param",
-		})
-		return
-	}
-
-	var req dto.ExchangeRateUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request",
-		})
-		return
-	}
-	if req.Rate == nil && req.IsActive == nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "At least one field (rate or is_active) must be provided for update",
+			"error": err.Error(),
 		})
 		return
 	}
 
-	appErr := h.exchangeRateService.UpdateExchangeRate(ctx, id, req)
+	result, total, next, appErr := h.exchangeRateService.ListExchangeRates(ctx, filter)
 	if appErr != nil {
 		c.JSON(appErr.Code, gin.H{
 			"error": appErr.Message,
 		})
 		return
 	}
-
+	exchangeRates := make([]dto.ExchangeRateResponse, 0, len(result))
+	for _, rate := range result {
+		exchangeRates = append(exchangeRates, toExchangeRateResponse(rate))
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"id": id,
-		"message": "Exchange rate updated successfully",
+		"message":        "Exchange rates fetched successfully",
+		"exchange_rates": exchangeRates,
+		"total":          total,
+		"next_cursor":    nextCursorOrNil(next),
 	})
 }
 
-func (h *ExchangeRateController) DeleteExchangeRate(c *gin.Context) {
-	ctx := c.Request.Context()
-
-	id, err := utils.ParseIDParam("id", c)
+func parseExchangeRateFilter(c *gin.Context) (dto.ExchangeRateFilter, error) {
+	params, err := parseListParams(c, dto.ExchangeRateSortFields, "id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid ID param",
-		})
-		return
+		return dto.ExchangeRateFilter{}, err
 	}
-
-	appErr := h.exchangeRateService.DeleteExchangeRate(ctx, id)
-	if appErr != nil {
-		c.JSON(appErr.Code, gin.H{
-			"error": appErr.Message,
-		})
-		return
+	filter := dto.ExchangeRateFilter{
+		FromCode: strings.ToUpper(c.Query("from")),
+		ToCode:   strings.ToUpper(c.Query("to")),
+		Sort:     params.Sort,
+		Cursor:   params.Cursor,
+		Limit:    params.Limit,
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Exchange rate deleted successfully",
-	})
+	if filter.FromCode != "" && len(filter.FromCode) != 3 {
+		return dto.ExchangeRateFilter{}, errInvalidQuery("from")
+	}
+	if filter.ToCode != "" && len(filter.ToCode) != 3 {
+		return dto.ExchangeRateFilter{}, errInvalidQuery("to")
+	}
+	if filter.IsActive, err = parseBoolQuery(c, "is_active"); err != nil {
+		return dto.ExchangeRateFilter{}, err
+	}
+	if v := c.Query("updated_since"); v != "" {
+		since, err := utils.ParseRangeStart(v)
+		if err != nil {
+			return dto.ExchangeRateFilter{}, errInvalidQuery("updated_since, " + err.Error())
+		}
+		filter.UpdatedSince = &since
+	}
+	return filter, nil
 }
 
-func (h *ExchangeRateController) SyncExchangeRates(c *gin.Context) {
-	ctx := c.Request.Context()
-
-	code, ok := c.Params.Get("code")
-	if !ok {
+// parsePairParams reads the :from and :to currency codes, writing the 400 itself
+func parsePairParams(c *gin.Context) (string, string, bool) {
+	from := strings.ToUpper(c.Param("from"))
+	to := strings.ToUpper(c.Param("to"))
+	if len(from) != 3 || len(to) != 3 {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Missing code parameter",
+			"error": "Invalid currency code",
 		})
-		return
+		return "", "", false
 	}
-	if code == "" || len(code) != 3 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid code parameter",
-		})
-		return
+	return from, to, true
+}
+
+func toExchangeRateResponse(exchangeRate models.ExchangeRate) dto.ExchangeRateResponse {
+	return dto.ExchangeRateResponse{
+		ID:             exchangeRate.ID,
+		FromCurrencyID: exchangeRate.FromCurrencyID,
+		ToCurrencyID:   exchangeRate.ToCurrencyID,
+		FromCurrency: dto.CurrencySummary{
+			ID:     exchangeRate.FromCurrencyID,
+			Code:   exchangeRate.FromCurrencyCode,
+			Name:   exchangeRate.FromCurrencyName,
+			Symbol: exchangeRate.FromCurrencySymbol,
+		},
+		ToCurrency: dto.CurrencySummary{
+			ID:     exchangeRate.ToCurrencyID,
+			Code:   exchangeRate.ToCurrencyCode,
+			Name:   exchangeRate.ToCurrencyName,
+			Symbol: exchangeRate.ToCurrencySymbol,
+		},
+		Rate:      exchangeRate.Rate,
+		Source:    exchangeRate.Source,
+		Quotes:    toRateQuoteResponses(exchangeRate.Quotes),
+		IsActive:  exchangeRate.IsActive,
+		Deleted:   exchangeRate.Deleted,
+		DeletedAt: exchangeRate.DeletedAt.Format(time.RFC3339),
+		UpdatedAt: exchangeRate.UpdatedAt.Format(time.RFC3339),
+		CreatedAt: exchangeRate.CreatedAt.Format(time.RFC3339),
+		CreatedBy: exchangeRate.CreatedBy,
+		UpdatedBy: exchangeRate.UpdatedBy,
 	}
-	code = strings.ToUpper(code)
+}
 
-	appErr := h.exchangeRateService.SyncExchangeRates(ctx, code)
-	if appErr != nil {
-		c.JSON(appErr.Code, gin.H{
-			"error": appErr.Message,
+func toRateQuoteResponses(quotes models.RateQuotes) []dto.RateQuote {
+	if len(quotes) == 0 {
+		return nil
+	}
+	resp := make([]dto.RateQuote, 0, len(quotes))
+	for _, q := range quotes {
+		resp = append(resp, dto.RateQuote{
+			Source:   q.Source,
+			Rate:     q.Rate,
+			Accepted: q.Accepted,
 		})
-		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"code":    code,
-		"message": "Exchange rates synced successfully",
-	})
+	return resp
 }
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"currency-converter/security"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSProvider interface {
+	JWKS() security.JWKSet
+}
+
+type JWKSController struct {
+	keys JWKSProvider
+}
+
+func NewJWKSController(keys JWKSProvider) *JWKSController {
+	return &JWKSController{
+		keys: keys,
+	}
+}
+
+// GetJWKS publishes the public keys access tokens can be verified with
+func (h *JWKSController) GetJWKS(c *gin.Context) {
+	// short cache so that a newly rotated key shows up quickly
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"currency-converter/utils"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// listParams are the sort, cursor and limit query params shared by the list endpoints
+type listParams struct {
+	Sort   string
+	Cursor *utils.Cursor
+	Limit  int
+}
+
+// parseListParams reads sort (a field, "-" in front for descending), cursor and limit.
+// A cursor only continues the sort order it was issued for
+func parseListParams(c *gin.Context, sortFields []string, defaultSort string) (listParams, error) {
+	params := listParams{
+		Sort:  defaultSort,
+		Limit: defaultListLimit,
+	}
+
+	if v := c.Query("sort"); v != "" {
+		field := strings.TrimPrefix(v, "-")
+		if !slices.Contains(sortFields, field) {
+			return listParams{}, errInvalidQuery("sort, expected one of " + strings.Join(sortFields, ", "))
+		}
+		params.Sort = v
+	}
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := utils.DecodeCursor(v)
+		if err != nil {
+			return listParams{}, errInvalidQuery("cursor")
+		}
+		if cursor.Sort != params.Sort {
+			return listParams{}, errInvalidQuery("cursor, it was issued for sort " + cursor.Sort)
+		}
+		params.Cursor = &cursor
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return listParams{}, errInvalidQuery("limit, expected 1 to " + strconv.Itoa(maxListLimit))
+		}
+		params.Limit = limit
+	}
+	return params, nil
+}
+
+// parseBoolQuery reads an optional boolean query param, nil when it is absent
+func parseBoolQuery(c *gin.Context, param string) (*bool, error) {
+	v := c.Query(param)
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, errInvalidQuery(param)
+	}
+	return &b, nil
+}
+
+// nextCursorOrNil renders the last page's empty cursor as null
+func nextCursorOrNil(next string) any {
+	if next == "" {
+		return nil
+	}
+	return next
+}
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/middleware"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuarantineService interface {
+	GetQuarantinedRates(ctx context.Context, status string) ([]models.QuarantinedRate, *utils.AppError)
+	ApproveQuarantinedRate(ctx context.Context, id int, reviewerID int) *utils.AppError
+	RejectQuarantinedRate(ctx context.Context, id int, reviewerID int) *utils.AppError
+}
+
+type QuarantineController struct {
+	quarantineService QuarantineService
+}
+
+func NewQuarantineController(quarantineService QuarantineService) *QuarantineController {
+	return &QuarantineController{
+		quarantineService: quarantineService,
+	}
+}
+
+func (h *QuarantineController) GetQuarantinedRates(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// pending by default, status=all lists every review state
+	status := c.DefaultQuery("status", models.QuarantinePending)
+	switch status {
+	case "all":
+		status = ""
+	case models.QuarantinePending, models.QuarantineApproved, models.QuarantineRejected, models.QuarantineSuperseded:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status, expected pending, approved, rejected, superseded or all",
+		})
+		return
+	}
+
+	result, appErr := h.quarantineService.GetQuarantinedRates(ctx, status)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	quarantined := make([]dto.QuarantinedRateResponse, 0, len(result))
+	for _, q := range result {
+		resp := dto.QuarantinedRateResponse{
+			ID:             q.ID,
+			ExchangeRateID: q.ExchangeRateID,
+			FromCurrencyID: q.FromCurrencyID,
+			ToCurrencyID:   q.ToCurrencyID,
+			Rate:           q.Rate,
+			PreviousRate:   q.PreviousRate,
+			Source:         q.Source,
+			Quotes:         toRateQuoteResponses(q.Quotes),
+			Reason:         q.Reason,
+			Status:         q.Status,
+			ReviewedBy:     q.ReviewedBy,
+			CreatedAt:      q.CreatedAt.Format(time.RFC3339),
+		}
+		if q.ReviewedAt != nil {
+			resp.ReviewedAt = q.ReviewedAt.Format(time.RFC3339)
+		}
+		quarantined = append(quarantined, resp)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Quarantined rates fetched successfully",
+		"quarantined_rates": quarantined,
+	})
+}
+
+func (h *QuarantineController) ApproveQuarantinedRate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	id, err := utils.ParseIDParam("id", c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID param",
+		})
+		return
+	}
+
+	appErr := h.quarantineService.ApproveQuarantinedRate(ctx, id, claims.UserID)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"message": "Quarantined rate approved and applied",
+	})
+}
+
+func (h *QuarantineController) RejectQuarantinedRate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	id, err := utils.ParseIDParam("id", c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID param",
+		})
+		return
+	}
+
+	appErr := h.quarantineService.RejectQuarantinedRate(ctx, id, claims.UserID)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"message": "Quarantined rate rejected",
+	})
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SyncRunService interface {
+	GetSyncRuns(ctx context.Context) ([]models.SyncRun, *utils.AppError)
+	SyncExchangeRates(ctx context.Context, code string) (dto.SyncResult, *utils.AppError)
+}
+
+type SyncRunController struct {
+	syncRunService SyncRunService
+}
+
+func NewSyncRunController(syncRunService SyncRunService) *SyncRunController {
+	return &SyncRunController{
+		syncRunService: syncRunService,
+	}
+}
+
+func (h *SyncRunController) GetSyncRuns(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	result, appErr := h.syncRunService.GetSyncRuns(ctx)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	runs := make([]dto.SyncRunResponse, 0, len(result))
+	for _, run := range result {
+		runs = append(runs, dto.SyncRunResponse{
+			BaseCode:   run.BaseCode,
+			LastRunAt:  run.LastRunAt.Format(time.RFC3339),
+			DurationMs: run.DurationMs,
+			Status:     run.Status,
+			Error:      run.Error,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Sync runs fetched successfully",
+		"sync_runs": runs,
+	})
+}
+
+// SyncExchangeRates syncs one base currency now, 409 while the scheduler or another sync runs
+func (h *SyncRunController) SyncExchangeRates(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	code, ok := c.Params.Get("code")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing code parameter",
+		})
+		return
+	}
+	if code == "" || len(code) != 3 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid code parameter",
+		})
+		return
+	}
+	code = strings.ToUpper(code)
+
+	result, appErr := h.syncRunService.SyncExchangeRates(ctx, code)
+	if appErr != nil {
+		c.JSON(appErr.Code, gin.H{
+			"error": appErr.Message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    code,
+		"source":  result.Source,
+		"message": "Exchange rates synced successfully",
+		"updated": result.Updated,
+		"skipped": result.Skipped,
+	})
+}
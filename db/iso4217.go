@@ -0,0 +1,62 @@
+package db
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"currency-converter/models"
+)
+
+// iso4217.csv holds code, numeric code, name, minor units, symbol and whether the
+// code is historic. Minor units are empty for funds and metals like XAU, and
+// codes without a common symbol use the code itself
+//
+//go:embed iso4217.csv
+var iso4217CSV []byte
+
+// ISO4217Currencies parses the embedded ISO 4217 catalogue,
+// historic and withdrawn codes come back inactive
+func ISO4217Currencies() ([]models.Currency, error) {
+	records, err := csv.NewReader(bytes.NewReader(iso4217CSV)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error in reading ISO 4217 dataset: %w", err)
+	}
+
+	currencies := make([]models.Currency, 0, len(records))
+	for i, record := range records[1:] { // skip the header
+		line := i + 2
+		if len(record[0]) != 3 || len(record[1]) != 3 {
+			return nil, fmt.Errorf("ISO 4217 dataset line %d: invalid code %q or numeric code %q", line, record[0], record[1])
+		}
+
+		currency := models.Currency{
+			Code:        record[0],
+			NumericCode: record[1],
+			Name:        record[2],
+			Symbol:      record[4],
+		}
+		if currency.Symbol == "" {
+			currency.Symbol = currency.Code
+		}
+
+		if record[3] != "" {
+			minorUnits, err := strconv.Atoi(record[3])
+			if err != nil {
+				return nil, fmt.Errorf("ISO 4217 dataset line %d: invalid minor units %q", line, record[3])
+			}
+			currency.MinorUnits = &minorUnits
+		}
+
+		historic, err := strconv.ParseBool(record[5])
+		if err != nil {
+			return nil, fmt.Errorf("ISO 4217 dataset line %d: invalid historic flag %q", line, record[5])
+		}
+		currency.IsActive = !historic
+
+		currencies = append(currencies, currency)
+	}
+	return currencies, nil
+}
@@ -0,0 +1,306 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrations are numbered pairs of files, 0002_add_x.up.sql and 0002_add_x.down.sql,
+// each one runs in its own transaction together with its schema_migrations row
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationLock serialises migrations run by several instances at once
+const migrationLock = 4217
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // nil when pending
+}
+
+type schemaMigration struct {
+	Version   int       `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name;not null"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrations returns the embedded migrations sorted by version
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named like 0001_name.up.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		data, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// LatestVersion is the version the schema has once every embedded migration is applied
+func LatestVersion() (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// CurrentVersion is the latest applied migration, 0 for an empty database
+func CurrentVersion(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return 0, nil
+	}
+	var version int
+	err := db.Model(&schemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// CheckVersion fails unless every embedded migration has been applied,
+// the server doesn't run against a schema older than its code
+func CheckVersion(db *gorm.DB) error {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	latest, err := LatestVersion()
+	if err != nil {
+		return err
+	}
+	if current < latest {
+		return fmt.Errorf("database schema is at version %d but %d is required, run currency-converter migrate up", current, latest)
+	}
+	return nil
+}
+
+// MigrateUp applies every pending migration and returns the ones it applied
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	latest, err := LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+	return MigrateTo(db, latest)
+}
+
+// MigrateDown rolls back the latest applied migration
+func MigrateDown(db *gorm.DB) ([]Migration, error) {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if current == 0 {
+		return nil, nil
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	// the version below the current one, MigrateTo refuses to go below the baseline
+	target := 0
+	for _, m := range migrations {
+		if m.Version < current {
+			target = m.Version
+		}
+	}
+	return MigrateTo(db, target)
+}
+
+// MigrateTo applies or rolls back migrations one at a time until the schema is at version.
+// It returns the migrations it ran, in the order it ran them
+func MigrateTo(db *gorm.DB, version int) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	// the baseline adopts a schema which predates the migrations, rolling it back would drop its data
+	if len(migrations) > 0 && version < migrations[0].Version {
+		return nil, fmt.Errorf("migration %d_%s is the baseline and can't be rolled back", migrations[0].Version, migrations[0].Name)
+	}
+	if !hasVersion(migrations, version) {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("error in creating schema_migrations: %w", err)
+	}
+
+	var ran []Migration
+	for {
+		step, done, err := migrateStep(db, migrations, version)
+		if err != nil {
+			return ran, err
+		}
+		if done {
+			return ran, nil
+		}
+		ran = append(ran, step)
+	}
+}
+
+// migrateStep runs the one migration which brings the schema closer to version,
+// done is true when it is already there
+func migrateStep(db *gorm.DB, migrations []Migration, version int) (Migration, bool, error) {
+	var step Migration
+	done := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLock).Error; err != nil {
+			return err
+		}
+
+		// read under the lock, another instance may have moved the schema meanwhile
+		var applied []schemaMigration
+		if err := tx.Order("version").Find(&applied).Error; err != nil {
+			return err
+		}
+		current := 0
+		if len(applied) > 0 {
+			current = applied[len(applied)-1].Version
+		}
+
+		switch {
+		case current < version:
+			next, ok := nextMigration(migrations, current)
+			if !ok {
+				return fmt.Errorf("no migration after version %d", current)
+			}
+			step = next
+			if err := tx.Exec(next.Up).Error; err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", next.Version, next.Name, err)
+			}
+			return tx.Create(&schemaMigration{Version: next.Version, Name: next.Name, AppliedAt: time.Now()}).Error
+
+		case current > version:
+			last := applied[len(applied)-1]
+			m, ok := findMigration(migrations, last.Version)
+			if !ok {
+				return fmt.Errorf("migration %d_%s is applied but unknown to this binary, roll it back with the binary that applied it", last.Version, last.Name)
+			}
+			step = m
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", m.Version, m.Name, err)
+			}
+			return tx.Delete(&schemaMigration{}, m.Version).Error
+		}
+
+		done = true
+		return nil
+	})
+	return step, done, err
+}
+
+// MigrationStatuses lists every known migration and when it was applied,
+// followed by applied versions this binary doesn't know about
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAt := map[int]schemaMigration{}
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		var applied []schemaMigration
+		if err := db.Order("version").Find(&applied).Error; err != nil {
+			return nil, err
+		}
+		for _, a := range applied {
+			appliedAt[a.Version] = a
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if a, ok := appliedAt[m.Version]; ok {
+			status.AppliedAt = &a.AppliedAt
+			delete(appliedAt, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, a := range appliedAt {
+		statuses = append(statuses, MigrationStatus{Version: a.Version, Name: a.Name, AppliedAt: &a.AppliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+func nextMigration(migrations []Migration, current int) (Migration, bool) {
+	for _, m := range migrations {
+		if m.Version > current {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+func findMigration(migrations []Migration, version int) (Migration, bool) {
+	for _, m := range migrations {
+		if m.Version == version {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+func hasVersion(migrations []Migration, version int) bool {
+	_, ok := findMigration(migrations, version)
+	return ok
+}
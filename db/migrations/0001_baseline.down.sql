@@ -0,0 +1,7 @@
+-- the baseline adopts tables which may predate the migrations, dropping them would lose
+-- every user, currency and rate. MigrateTo refuses to get here, this guards running it by hand
+DO $$
+BEGIN
+	RAISE EXCEPTION 'migration 0001_baseline can''t be rolled back';
+END
+$$;
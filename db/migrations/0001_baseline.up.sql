@@ -0,0 +1,247 @@
+-- Baseline: the schema as it was built by gorm AutoMigrate and the raw statements of the old db.Migrate.
+-- Every statement is idempotent. An empty database gets the tables as below, one created by an
+-- older AutoMigrate keeps its tables and rows and gets the columns, indexes and constraints it
+-- misses from the ALTER TABLE statements which follow the CREATE TABLE statements.
+
+CREATE TABLE IF NOT EXISTS users (
+	id            bigserial PRIMARY KEY,
+	email         text NOT NULL,
+	password_hash text NOT NULL,
+	role          text NOT NULL DEFAULT 'viewer',
+	created_at    timestamptz,
+	updated_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS currencies (
+	id                      bigserial PRIMARY KEY,
+	code                    varchar(3) NOT NULL,
+	name                    text NOT NULL,
+	symbol                  text NOT NULL,
+	numeric_code            varchar(3),
+	is_active               boolean DEFAULT true,
+	minor_units             bigint,
+	cash_rounding_increment numeric(20,10),
+	deleted                 boolean NOT NULL DEFAULT false,
+	created_at              timestamptz,
+	updated_at              timestamptz,
+	deleted_at              timestamptz,
+	created_by              bigint,
+	updated_by              bigint
+);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+	id               bigserial PRIMARY KEY,
+	from_currency_id bigint NOT NULL,
+	to_currency_id   bigint NOT NULL,
+	rate             numeric(20,10) NOT NULL,
+	source           text NOT NULL DEFAULT 'manual',
+	quotes           jsonb,
+	is_active        boolean DEFAULT true,
+	deleted          boolean NOT NULL DEFAULT false,
+	created_at       timestamptz,
+	updated_at       timestamptz,
+	deleted_at       timestamptz,
+	created_by       bigint,
+	updated_by       bigint
+);
+
+CREATE TABLE IF NOT EXISTS exchange_rate_histories (
+	id               bigserial PRIMARY KEY,
+	exchange_rate_id bigint NOT NULL,
+	from_currency_id bigint NOT NULL,
+	to_currency_id   bigint NOT NULL,
+	rate             numeric(20,10) NOT NULL,
+	source           text NOT NULL,
+	quotes           jsonb,
+	effective_at     timestamptz NOT NULL,
+	created_at       timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_exchange_rate_histories_exchange_rate_id ON exchange_rate_histories (exchange_rate_id);
+
+CREATE TABLE IF NOT EXISTS sync_runs (
+	base_code   varchar(3) PRIMARY KEY,
+	last_run_at timestamptz NOT NULL,
+	duration_ms bigint NOT NULL,
+	status      text NOT NULL,
+	error       text
+);
+
+CREATE TABLE IF NOT EXISTS quarantined_rates (
+	id               bigserial PRIMARY KEY,
+	exchange_rate_id bigint,
+	from_currency_id bigint NOT NULL,
+	to_currency_id   bigint NOT NULL,
+	rate             numeric(20,10) NOT NULL,
+	previous_rate    numeric(20,10),
+	source           text NOT NULL,
+	quotes           jsonb,
+	reason           text NOT NULL,
+	status           text NOT NULL DEFAULT 'pending',
+	reviewed_at      timestamptz,
+	created_at       timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_quarantined_rates_status ON quarantined_rates (status);
+
+CREATE TABLE IF NOT EXISTS exchange_rate_changes (
+	id               bigserial PRIMARY KEY,
+	exchange_rate_id bigint NOT NULL,
+	rate             numeric(20,10),
+	is_active        boolean,
+	status           text NOT NULL DEFAULT 'pending',
+	proposed_by      bigint NOT NULL,
+	reviewed_by      bigint,
+	reviewed_at      timestamptz,
+	quarantine_id    bigint,
+	created_at       timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_exchange_rate_changes_exchange_rate_id ON exchange_rate_changes (exchange_rate_id);
+CREATE INDEX IF NOT EXISTS idx_exchange_rate_changes_status ON exchange_rate_changes (status);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id         bigserial PRIMARY KEY,
+	user_id    bigint NOT NULL,
+	family_id  text NOT NULL,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	rotated_at timestamptz,
+	revoked_at timestamptz,
+	created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	token_id   text PRIMARY KEY,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id           bigserial PRIMARY KEY,
+	user_id      bigint NOT NULL,
+	name         text NOT NULL,
+	prefix       text NOT NULL,
+	key_hash     text NOT NULL,
+	scopes       jsonb,
+	expires_at   timestamptz,
+	last_used_at timestamptz,
+	revoked_at   timestamptz,
+	created_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+	id         bigserial PRIMARY KEY,
+	actor_id   bigint,
+	action     text NOT NULL,
+	entity     text NOT NULL,
+	entity_id  bigint NOT NULL,
+	before     jsonb,
+	after      jsonb,
+	changes    jsonb,
+	request_id text,
+	created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events (entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_request_id ON audit_events (request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+
+-- columns added after a table was first created, a table built by an older AutoMigrate lacks them.
+-- The original schema had no roles, its users become viewers as they would have with AutoMigrate
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'viewer';
+
+ALTER TABLE currencies
+	ADD COLUMN IF NOT EXISTS numeric_code            varchar(3),
+	ADD COLUMN IF NOT EXISTS minor_units             bigint,
+	ADD COLUMN IF NOT EXISTS cash_rounding_increment numeric(20,10),
+	ADD COLUMN IF NOT EXISTS created_by              bigint,
+	ADD COLUMN IF NOT EXISTS updated_by              bigint;
+
+-- rates were stored as double precision before exact decimals, a no-op once the column is numeric
+ALTER TABLE exchange_rates
+	ADD COLUMN IF NOT EXISTS source     text NOT NULL DEFAULT 'manual',
+	ADD COLUMN IF NOT EXISTS quotes     jsonb,
+	ADD COLUMN IF NOT EXISTS created_by bigint,
+	ADD COLUMN IF NOT EXISTS updated_by bigint,
+	ALTER COLUMN rate TYPE numeric(20,10) USING rate::numeric(20,10);
+
+ALTER TABLE exchange_rate_histories
+	ADD COLUMN IF NOT EXISTS quotes jsonb;
+
+-- one live currency per code and one live rate per pair, deleted rows are kept
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_code_not_deleted
+	ON currencies (code)
+	WHERE deleted = false;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_uique_exchange_rate_not_deleted
+	ON exchange_rates (from_currency_id, to_currency_id)
+	WHERE deleted = false;
+
+-- point in time lookups pick the latest entry of a pair before a given moment
+CREATE INDEX IF NOT EXISTS idx_exchange_rate_histories_pair_effective_at
+	ON exchange_rate_histories (from_currency_id, to_currency_id, effective_at DESC);
+
+-- indexes behind the filters and sort orders of the list endpoints,
+-- pg_trgm is a trusted extension, the database owner can create it
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_currencies_name_trgm
+	ON currencies USING gin (name gin_trgm_ops)
+	WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_currencies_code_prefix
+	ON currencies (code text_pattern_ops)
+	WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_currencies_is_active_code
+	ON currencies (is_active, code, id)
+	WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_currencies_name_id
+	ON currencies (name, id)
+	WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_to_currency
+	ON exchange_rates (to_currency_id)
+	WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_is_active
+	ON exchange_rates (is_active, id)
+	WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_updated_at
+	ON exchange_rates (updated_at, id)
+	WHERE deleted = false;
+
+-- constraints are NOT VALID, they hold for every new or updated row without failing over old rows.
+-- Once those are cleaned up the constraint can be checked for the whole table with
+-- ALTER TABLE ... VALIDATE CONSTRAINT
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_exchange_rates_from_currency') THEN
+		ALTER TABLE exchange_rates ADD CONSTRAINT fk_exchange_rates_from_currency
+			FOREIGN KEY (from_currency_id) REFERENCES currencies (id) NOT VALID;
+	END IF;
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_exchange_rates_to_currency') THEN
+		ALTER TABLE exchange_rates ADD CONSTRAINT fk_exchange_rates_to_currency
+			FOREIGN KEY (to_currency_id) REFERENCES currencies (id) NOT VALID;
+	END IF;
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_exchange_rates_rate_positive') THEN
+		ALTER TABLE exchange_rates ADD CONSTRAINT chk_exchange_rates_rate_positive
+			CHECK (rate > 0) NOT VALID;
+	END IF;
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_exchange_rates_different_currencies') THEN
+		ALTER TABLE exchange_rates ADD CONSTRAINT chk_exchange_rates_different_currencies
+			CHECK (from_currency_id <> to_currency_id) NOT VALID;
+	END IF;
+END $$;
+
+-- adopt rates which were stored before history existed
+INSERT INTO exchange_rate_histories (
+	exchange_rate_id, from_currency_id, to_currency_id, rate, source, effective_at, created_at
+)
+SELECT er.id, er.from_currency_id, er.to_currency_id, er.rate, 'baseline', GREATEST(er.created_at, er.updated_at), NOW()
+FROM exchange_rates er
+WHERE NOT EXISTS (
+	SELECT 1 FROM exchange_rate_histories h WHERE h.exchange_rate_id = er.id
+);
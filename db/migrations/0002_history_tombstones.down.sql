@@ -0,0 +1,4 @@
+DELETE FROM exchange_rate_histories WHERE retired;
+
+ALTER TABLE exchange_rate_histories
+	DROP COLUMN IF EXISTS retired;
@@ -0,0 +1,29 @@
+-- a retired history entry is a tombstone, the rate was deleted or deactivated from effective_at on
+ALTER TABLE exchange_rate_histories
+	ADD COLUMN IF NOT EXISTS retired boolean NOT NULL DEFAULT false;
+
+-- rates which are already deleted or inactive get their tombstone, no earlier
+-- than their latest entry so that it really is the latest one
+INSERT INTO exchange_rate_histories (
+	exchange_rate_id, from_currency_id, to_currency_id, rate, source, quotes, retired, effective_at, created_at
+)
+SELECT er.id, er.from_currency_id, er.to_currency_id, er.rate, er.source, er.quotes, true,
+	GREATEST(latest.effective_at, CASE WHEN er.deleted THEN er.deleted_at ELSE er.updated_at END), NOW()
+FROM exchange_rates er
+JOIN LATERAL (
+	SELECT h.effective_at, h.retired
+	FROM exchange_rate_histories h
+	WHERE h.from_currency_id = er.from_currency_id AND h.to_currency_id = er.to_currency_id
+	ORDER BY h.effective_at DESC, h.id DESC
+	LIMIT 1
+) latest ON true
+WHERE (er.deleted OR NOT er.is_active)
+AND NOT latest.retired
+-- a deleted rate whose pair has a live rate again is superseded, not retired
+AND NOT EXISTS (
+	SELECT 1 FROM exchange_rates live
+	WHERE live.from_currency_id = er.from_currency_id
+	AND live.to_currency_id = er.to_currency_id
+	AND live.deleted = false
+	AND live.id <> er.id
+);
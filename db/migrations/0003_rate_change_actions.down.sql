@@ -0,0 +1,8 @@
+-- proposed creates and deletes can't be told apart from updates without the action column
+DELETE FROM exchange_rate_changes WHERE action <> 'update' OR exchange_rate_id IS NULL;
+
+ALTER TABLE exchange_rate_changes
+	ALTER COLUMN exchange_rate_id SET NOT NULL,
+	DROP COLUMN IF EXISTS to_currency_id,
+	DROP COLUMN IF EXISTS from_currency_id,
+	DROP COLUMN IF EXISTS action;
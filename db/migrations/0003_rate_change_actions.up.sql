@@ -0,0 +1,7 @@
+-- creating and deleting a rate are proposed and approved like updates,
+-- a proposed create names its pair and has no exchange rate until it is approved
+ALTER TABLE exchange_rate_changes
+	ADD COLUMN IF NOT EXISTS action           text NOT NULL DEFAULT 'update',
+	ADD COLUMN IF NOT EXISTS from_currency_id bigint,
+	ADD COLUMN IF NOT EXISTS to_currency_id   bigint,
+	ALTER COLUMN exchange_rate_id DROP NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE quarantined_rates DROP COLUMN IF EXISTS reviewed_by;
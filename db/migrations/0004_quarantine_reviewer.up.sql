@@ -0,0 +1,2 @@
+-- who approved or rejected a quarantined rate, reviewed_at is already there
+ALTER TABLE quarantined_rates ADD COLUMN IF NOT EXISTS reviewed_by bigint;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_quarantined_rates_pending_pair;
+
+-- older binaries don't know the superseded status
+UPDATE quarantined_rates SET status = 'rejected' WHERE status = 'superseded';
@@ -0,0 +1,16 @@
+-- a pair has at most one pending quarantined rate, a newer one supersedes it.
+-- Of the duplicates already queued only the latest stays pending
+UPDATE quarantined_rates q
+SET status = 'superseded'
+WHERE q.status = 'pending'
+	AND EXISTS (
+		SELECT 1 FROM quarantined_rates newer
+		WHERE newer.from_currency_id = q.from_currency_id
+			AND newer.to_currency_id = q.to_currency_id
+			AND newer.status = 'pending'
+			AND newer.id > q.id
+	);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quarantined_rates_pending_pair
+	ON quarantined_rates (from_currency_id, to_currency_id)
+	WHERE status = 'pending';
@@ -0,0 +1,2 @@
+ALTER TABLE exchange_rates DROP COLUMN IF EXISTS deactivated_at;
+ALTER TABLE currencies DROP COLUMN IF EXISTS deactivated_at;
@@ -0,0 +1,4 @@
+-- when a currency was deactivated, its rates deactivated along with it get the same
+-- value so that reactivating the currency can bring them back
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS deactivated_at timestamptz;
+ALTER TABLE exchange_rates ADD COLUMN IF NOT EXISTS deactivated_at timestamptz;
@@ -0,0 +1,262 @@
+package decimal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxStringScale caps the digits printed for values which have no finite
+// decimal expansion, e.g. the result of a division by 3
+const maxStringScale = 40
+
+// Parse caps the digits and the exponent of a literal, input like 1e1000000 would
+// otherwise cost a huge big.Int and print a megabyte of zeros
+const (
+	maxParseDigits   = 50
+	maxParseExponent = 50
+)
+
+var (
+	ErrInvalidDecimal = errors.New("invalid decimal")
+	ErrDivisionByZero = errors.New("division by zero")
+)
+
+// Decimal is an exact decimal number backed by a big.Rat,
+// the zero value is 0 and ready to use
+type Decimal struct {
+	rat *big.Rat
+}
+
+func Zero() Decimal {
+	return Decimal{}
+}
+
+func NewFromInt(v int64) Decimal {
+	return Decimal{rat: new(big.Rat).SetInt64(v)}
+}
+
+// Parse accepts plain decimal literals like "12", "-0.015" or "1.5e-3",
+// with at most maxParseDigits digits and an exponent of at most maxParseExponent
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if !isDecimalLiteral(s) || !withinParseLimits(s) {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	return Decimal{rat: r}, nil
+}
+
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) value() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return d.rat
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Add(d.value(), o.value())}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Sub(d.value(), o.value())}
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Mul(d.value(), o.value())}
+}
+
+// Div is exact, round the result before printing or storing it
+func (d Decimal) Div(o Decimal) (Decimal, error) {
+	if o.IsZero() {
+		return Decimal{}, ErrDivisionByZero
+	}
+	return Decimal{rat: new(big.Rat).Quo(d.value(), o.value())}, nil
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{rat: new(big.Rat).Neg(d.value())}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{rat: new(big.Rat).Abs(d.value())}
+}
+
+func (d Decimal) Cmp(o Decimal) int {
+	return d.value().Cmp(o.value())
+}
+
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Round rounds to the given number of digits after the decimal point
+func (d Decimal) Round(scale int, mode RoundingMode) Decimal {
+	r := d.value()
+	factor := pow10(scale)
+
+	num := new(big.Int).Mul(r.Num(), factor)
+	q, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+
+	if rem.Sign() != 0 && mode.roundAway(q, rem, r.Denom(), num.Sign()) {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+
+	return Decimal{rat: new(big.Rat).SetFrac(q, factor)}
+}
+
+// RoundToIncrement rounds to a multiple of increment, e.g. 0.05 for cash amounts,
+// a zero or negative increment leaves the value unchanged
+func (d Decimal) RoundToIncrement(increment Decimal, mode RoundingMode) Decimal {
+	if increment.Sign() <= 0 {
+		return d
+	}
+	steps, _ := d.Div(increment)
+	return steps.Round(0, mode).Mul(increment)
+}
+
+// StringFixed prints exactly scale digits after the decimal point,
+// rounding half to even if needed
+func (d Decimal) StringFixed(scale int) string {
+	return d.Round(scale, HalfEven).value().FloatString(scale)
+}
+
+// String prints the shortest exact representation, values without a finite
+// decimal expansion are rounded half to even at maxStringScale digits
+func (d Decimal) String() string {
+	r := d.value()
+	for scale := 0; scale <= maxStringScale; scale++ {
+		if new(big.Int).Mod(pow10(scale), r.Denom()).Sign() == 0 {
+			return r.FloatString(scale)
+		}
+	}
+	s := d.StringFixed(maxStringScale)
+	return strings.TrimRight(strings.TrimRight(s, "0"), ".")
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts both JSON numbers and quoted strings
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidDecimal, s)
+		}
+		s = unquoted
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d *Decimal) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		*d = NewFromInt(v)
+		return nil
+	case float64:
+		s = fmt.Sprint(v)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidDecimal, src)
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// isDecimalLiteral rejects the fraction and hex forms big.Rat would otherwise accept
+func isDecimalLiteral(s string) bool {
+	if s == "" {
+		return false
+	}
+	digits, dot, exp := false, false, false
+	for i, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c == '+' || c == '-':
+			if i != 0 && s[i-1] != 'e' && s[i-1] != 'E' {
+				return false
+			}
+		case c == '.':
+			if dot || exp {
+				return false
+			}
+			dot = true
+		case c == 'e' || c == 'E':
+			if exp || !digits {
+				return false
+			}
+			exp = true
+		default:
+			return false
+		}
+	}
+	return digits
+}
+
+// withinParseLimits checks a decimal literal against maxParseDigits and maxParseExponent
+func withinParseLimits(s string) bool {
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(s), "e")
+	digits := 0
+	for _, c := range mantissa {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	if digits > maxParseDigits {
+		return false
+	}
+	if !hasExponent {
+		return true
+	}
+	exp, err := strconv.Atoi(exponent)
+	return err == nil && exp >= -maxParseExponent && exp <= maxParseExponent
+}
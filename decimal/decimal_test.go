@@ -0,0 +1,278 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var modes = []RoundingMode{HalfEven, HalfUp, HalfDown, Up, Down, Ceiling, Floor}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		in    string
+		scale int
+		want  [7]string // by mode, in the order of modes
+	}{
+		// ties
+		{"2.5", 0, [7]string{"2", "3", "2", "3", "2", "3", "2"}},
+		{"-2.5", 0, [7]string{"-2", "-3", "-2", "-3", "-2", "-2", "-3"}},
+		{"3.5", 0, [7]string{"4", "4", "3", "4", "3", "4", "3"}},
+		{"-3.5", 0, [7]string{"-4", "-4", "-3", "-4", "-3", "-3", "-4"}},
+		{"1.005", 2, [7]string{"1", "1.01", "1", "1.01", "1", "1.01", "1"}},
+		{"-0.125", 2, [7]string{"-0.12", "-0.13", "-0.12", "-0.13", "-0.12", "-0.12", "-0.13"}},
+		// below and above half
+		{"2.4", 0, [7]string{"2", "2", "2", "3", "2", "3", "2"}},
+		{"-2.6", 0, [7]string{"-3", "-3", "-3", "-3", "-2", "-2", "-3"}},
+		{"0.0149", 2, [7]string{"0.01", "0.01", "0.01", "0.02", "0.01", "0.02", "0.01"}},
+		// nothing to round
+		{"2", 0, [7]string{"2", "2", "2", "2", "2", "2", "2"}},
+		{"-1.25", 2, [7]string{"-1.25", "-1.25", "-1.25", "-1.25", "-1.25", "-1.25", "-1.25"}},
+		{"0", 2, [7]string{"0", "0", "0", "0", "0", "0", "0"}},
+	}
+
+	for _, tt := range tests {
+		for i, mode := range modes {
+			got := MustParse(tt.in).Round(tt.scale, mode)
+			if got.String() != tt.want[i] {
+				t.Errorf("Round(%s, %d, %s) = %s, want %s", tt.in, tt.scale, mode, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestRoundToIncrement(t *testing.T) {
+	tests := []struct {
+		in        string
+		increment string
+		mode      RoundingMode
+		want      string
+	}{
+		{"1.12", "0.05", HalfUp, "1.1"},
+		{"1.13", "0.05", HalfUp, "1.15"},
+		{"1.125", "0.05", HalfUp, "1.15"},
+		{"1.125", "0.05", HalfEven, "1.1"},
+		{"1.175", "0.05", HalfEven, "1.2"},
+		{"-1.125", "0.05", HalfUp, "-1.15"},
+		{"-1.125", "0.05", Ceiling, "-1.1"},
+		{"1.01", "0.05", Up, "1.05"},
+		{"1.04", "0.05", Down, "1"},
+		{"7", "5", HalfEven, "5"},
+		{"1.13", "0", HalfUp, "1.13"},
+		{"1.13", "-0.05", HalfUp, "1.13"},
+	}
+
+	for _, tt := range tests {
+		got := MustParse(tt.in).RoundToIncrement(MustParse(tt.increment), tt.mode)
+		if got.String() != tt.want {
+			t.Errorf("RoundToIncrement(%s, %s, %s) = %s, want %s", tt.in, tt.increment, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	third, _ := NewFromInt(1).Div(NewFromInt(3))
+	twoThirds, _ := NewFromInt(2).Div(NewFromInt(3))
+
+	tests := []struct {
+		in   Decimal
+		want string
+	}{
+		{Decimal{}, "0"},
+		{MustParse("-0"), "0"},
+		{MustParse("1.50"), "1.5"},
+		{MustParse("-0.015"), "-0.015"},
+		{MustParse("1e3"), "1000"},
+		{MustParse("1.5e-3"), "0.0015"},
+		{MustParse("83.1000000000"), "83.1"},
+		{third, "0." + strings.Repeat("3", maxStringScale)},
+		{twoThirds, "0." + strings.Repeat("6", maxStringScale-1) + "7"},
+		{twoThirds.Neg(), "-0." + strings.Repeat("6", maxStringScale-1) + "7"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestStringFixed(t *testing.T) {
+	if got := MustParse("1.005").StringFixed(2); got != "1.00" {
+		t.Errorf("StringFixed(2) = %s, want 1.00", got)
+	}
+	if got := MustParse("-2").StringFixed(3); got != "-2.000" {
+		t.Errorf("StringFixed(3) = %s, want -2.000", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	valid := []string{
+		"12", "-0.015", "+3", " 4.5 ", "1.5e-3", "2E+2", ".5", "5.",
+		"1e50", "1e-50",
+		strings.Repeat("9", maxParseDigits),
+		"0." + strings.Repeat("1", maxParseDigits-1),
+	}
+	for _, s := range valid {
+		if _, err := Parse(s); err != nil {
+			t.Errorf("Parse(%q): %v", s, err)
+		}
+	}
+
+	invalid := []string{
+		"", "-", ".", "e5", "1e", "1/2", "0x10", "1..2", "1e2e3", "1.2.3", "--1", "1-", "abc", "NaN", "Inf",
+		"1e51", "1e-51", "1e1000000", "1e99999999999999999999",
+		strings.Repeat("9", maxParseDigits+1),
+		"0." + strings.Repeat("1", maxParseDigits),
+	}
+	for _, s := range invalid {
+		if _, err := Parse(s); !errors.Is(err, ErrInvalidDecimal) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidDecimal", s, err)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := MustParse("0.1"), MustParse("0.2")
+	if got := a.Add(b); got.Cmp(MustParse("0.3")) != 0 {
+		t.Errorf("0.1 + 0.2 = %s, want exactly 0.3", got)
+	}
+	if got := a.Sub(b); got.String() != "-0.1" {
+		t.Errorf("0.1 - 0.2 = %s, want -0.1", got)
+	}
+	if got := a.Mul(b); got.String() != "0.02" {
+		t.Errorf("0.1 * 0.2 = %s, want 0.02", got)
+	}
+	if got, err := b.Div(a); err != nil || got.String() != "2" {
+		t.Errorf("0.2 / 0.1 = %s, %v, want 2", got, err)
+	}
+	if _, err := a.Div(Zero()); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("division by zero error = %v, want ErrDivisionByZero", err)
+	}
+	if got := MustParse("-1.5").Abs(); got.String() != "1.5" {
+		t.Errorf("Abs(-1.5) = %s, want 1.5", got)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type payload struct {
+		Rate   Decimal  `json:"rate"`
+		Amount *Decimal `json:"amount"`
+	}
+
+	in := payload{Rate: MustParse("83.1234567891")}
+	amount := MustParse("-0.05")
+	in.Amount = &amount
+
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"rate":83.1234567891,"amount":-0.05}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	var out payload
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.Rate.Cmp(in.Rate) != 0 || out.Amount == nil || out.Amount.Cmp(*in.Amount) != 0 {
+		t.Errorf("round trip = %s, %v, want %s, %s", out.Rate, out.Amount, in.Rate, in.Amount)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`12.5`, "12.5"},
+		{`"12.5"`, "12.5"},
+		{`-1e-2`, "-0.01"},
+		{`"1.5e3"`, "1500"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if d.String() != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, d, tt.want)
+		}
+	}
+
+	// null leaves the value as it was
+	d := MustParse("7")
+	if err := d.UnmarshalJSON([]byte("null")); err != nil || d.String() != "7" {
+		t.Errorf("UnmarshalJSON(null) = %s, %v, want 7 unchanged", d, err)
+	}
+
+	for _, in := range []string{`"12`, `12"`, `""`, `"1/2"`, `"0x10"`, `"abc"`, `1e1000000`, `"1e1000000"`} {
+		var d Decimal
+		if err := d.UnmarshalJSON([]byte(in)); !errors.Is(err, ErrInvalidDecimal) {
+			t.Errorf("UnmarshalJSON(%s) error = %v, want ErrInvalidDecimal", in, err)
+		}
+	}
+}
+
+func TestValueScanRoundTrip(t *testing.T) {
+	for _, s := range []string{"0", "83.1", "-0.0000000001", "1234567890.0123456789"} {
+		in := MustParse(s)
+		v, err := in.Value()
+		if err != nil {
+			t.Fatalf("Value(%s): %v", s, err)
+		}
+		var out Decimal
+		if err := out.Scan(v); err != nil {
+			t.Fatalf("Scan(%v): %v", v, err)
+		}
+		if out.Cmp(in) != 0 {
+			t.Errorf("Scan(Value(%s)) = %s", s, out)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  any
+		want string
+	}{
+		{"83.1000000000", "83.1"},
+		{[]byte("0.0150000000"), "0.015"},
+		{int64(5), "5"},
+		{float64(0.5), "0.5"},
+		{nil, "0"},
+	}
+	for _, tt := range tests {
+		d := MustParse("9")
+		if err := d.Scan(tt.src); err != nil {
+			t.Errorf("Scan(%v): %v", tt.src, err)
+			continue
+		}
+		if d.String() != tt.want {
+			t.Errorf("Scan(%v) = %s, want %s", tt.src, d, tt.want)
+		}
+	}
+
+	var d Decimal
+	if err := d.Scan(true); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("Scan(bool) error = %v, want ErrInvalidDecimal", err)
+	}
+	if err := d.Scan("1/2"); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("Scan(1/2) error = %v, want ErrInvalidDecimal", err)
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for _, mode := range modes {
+		got, err := ParseRoundingMode(" " + strings.ToUpper(string(mode)) + " ")
+		if err != nil || got != mode {
+			t.Errorf("ParseRoundingMode(%s) = %s, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseRoundingMode("bankers"); err == nil {
+		t.Error("ParseRoundingMode(bankers) succeeded, want an error")
+	}
+}
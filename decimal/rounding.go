@@ -0,0 +1,53 @@
+package decimal
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type RoundingMode string
+
+const (
+	HalfEven RoundingMode = "half-even" // banker's rounding
+	HalfUp   RoundingMode = "half-up"   // ties away from zero
+	HalfDown RoundingMode = "half-down" // ties towards zero
+	Up       RoundingMode = "up"        // away from zero
+	Down     RoundingMode = "down"      // towards zero (truncate)
+	Ceiling  RoundingMode = "ceiling"   // towards +infinity
+	Floor    RoundingMode = "floor"     // towards -infinity
+)
+
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	mode := RoundingMode(strings.ToLower(strings.TrimSpace(s)))
+	switch mode {
+	case HalfEven, HalfUp, HalfDown, Up, Down, Ceiling, Floor:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown rounding mode %q", s)
+}
+
+// roundAway reports whether the truncated quotient q has to move one unit
+// away from zero, rem is the non-zero remainder of the division by denom
+func (m RoundingMode) roundAway(q, rem, denom *big.Int, sign int) bool {
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(denom)
+
+	switch m {
+	case Up:
+		return true
+	case Down:
+		return false
+	case Ceiling:
+		return sign > 0
+	case Floor:
+		return sign < 0
+	case HalfUp:
+		return cmp >= 0
+	case HalfDown:
+		return cmp > 0
+	default: // HalfEven
+		return cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+	}
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+// AuditFilter selects audit events, nil and empty fields are not filtered on
+type AuditFilter struct {
+	Entity   string
+	EntityID *int
+	ActorID  *int
+	Action   string
+	From     *time.Time
+	To       *time.Time
+	Page     int // 1 based
+	PageSize int
+}
+
+type AuditEventResponse struct {
+	ID        int64          `json:"id"`
+	ActorID   *int           `json:"actor_id"`
+	Action    string         `json:"action"`
+	Entity    string         `json:"entity"`
+	EntityID  int            `json:"entity_id"`
+	Before    map[string]any `json:"before"`
+	After     map[string]any `json:"after"`
+	Changes   map[string]any `json:"changes"`
+	RequestID string         `json:"request_id"`
+	CreatedAt string         `json:"created_at"`
+}
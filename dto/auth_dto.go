@@ -6,14 +6,22 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	UserId  int    `json:"user_id"`
-	Token   string `json:"token"`
-	Message string `json:"message"`
+	UserId       int    `json:"user_id"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // seconds until the access token expires
+	Message      string `json:"message"`
 }
 
 type LoginResult struct {
-	ID    int
-	Token string
+	ID           int
+	Token        string
+	RefreshToken string
+	ExpiresIn    int
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type RegisterRequest struct {
@@ -26,3 +34,23 @@ type RegisterResponse struct {
 	Message string `json:"message"`
 }
 
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type APIKeyCreateRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expires_in_days"` // never expires when omitted
+}
+
+type APIKeyResponse struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
@@ -1,20 +1,114 @@
 package dto
 
+import (
+	"currency-converter/decimal"
+	"currency-converter/utils"
+	"time"
+)
+
 type CurrencyConversionResponse struct {
-	From            string  `json:"from"`
-	To              string  `json:"to"`
-	Amount          float64 `json:"amount"`
-	Rate            float64 `json:"rate"`
-	ConvertedAmount float64 `json:"converted_amount"`
+	From               string           `json:"from"`
+	To                 string           `json:"to"`
+	Amount             decimal.Decimal  `json:"amount"`
+	Rate               decimal.Decimal  `json:"rate"`
+	ConvertedAmount    decimal.Decimal  `json:"converted_amount"`
+	RawConvertedAmount decimal.Decimal  `json:"raw_converted_amount"`
+	CashAmount         *decimal.Decimal `json:"cash_amount,omitempty"`
+	MinorUnits         int              `json:"minor_units"`
+	RoundingMode       string           `json:"rounding_mode"`
+	At                 string           `json:"at,omitempty"`
+	Legs               []ConversionLeg  `json:"legs"`
 }
 
 type ConversionCmd struct {
 	From   string
 	To     string
-	Amount float64
+	Amount decimal.Decimal
+	At     *time.Time // nil converts with the current rates
 }
 
 type ConversionResult struct {
-	ConvertedAmount float64
-	Rate            float64
+	ConvertedAmount    decimal.Decimal // rounded to MinorUnits
+	RawConvertedAmount decimal.Decimal
+	CashAmount         *decimal.Decimal // only for currencies with a cash rounding increment
+	Rate               decimal.Decimal
+	RoundingMode       decimal.RoundingMode
+	MinorUnits         int
+	Legs               []ConversionLeg
+}
+
+// ConversionLeg is one hop of a conversion, a direct conversion has a single leg.
+// An inverted leg has no stored rate of its own, its rate is 1 / the stored To->From rate
+type ConversionLeg struct {
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Rate     decimal.Decimal `json:"rate"`
+	Inverted bool            `json:"inverted"`
+}
+
+type BatchConversionRequest struct {
+	At    string                `json:"at"` // optional, RFC3339 or YYYY-MM-DD, applies to every item
+	Items []BatchConversionItem `json:"items" binding:"required,min=1,max=1000,dive"`
+}
+
+type BatchConversionItem struct {
+	From   string          `json:"from" binding:"required,len=3"`
+	To     string          `json:"to" binding:"required,len=3"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// BatchConversionResult holds either the result of an item or its error
+type BatchConversionResult struct {
+	Result ConversionResult
+	Err    *utils.AppError
+}
+
+type BatchConversionItemResponse struct {
+	Index              int              `json:"index"`
+	From               string           `json:"from"`
+	To                 string           `json:"to"`
+	Amount             decimal.Decimal  `json:"amount"`
+	Rate               *decimal.Decimal `json:"rate,omitempty"`
+	ConvertedAmount    *decimal.Decimal `json:"converted_amount,omitempty"`
+	RawConvertedAmount *decimal.Decimal `json:"raw_converted_amount,omitempty"`
+	CashAmount         *decimal.Decimal `json:"cash_amount,omitempty"`
+	MinorUnits         *int             `json:"minor_units,omitempty"`
+	Legs               []ConversionLeg  `json:"legs,omitempty"`
+	Error              *ItemError       `json:"error,omitempty"`
+}
+
+type ItemError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type MultiConversionResult struct {
+	RoundingMode decimal.RoundingMode
+	Conversions  []TargetConversion
+	Missing      []MissingTarget
+}
+
+type TargetConversion struct {
+	To                 string           `json:"to"`
+	Rate               decimal.Decimal  `json:"rate"`
+	ConvertedAmount    decimal.Decimal  `json:"converted_amount"`
+	RawConvertedAmount decimal.Decimal  `json:"raw_converted_amount"`
+	CashAmount         *decimal.Decimal `json:"cash_amount,omitempty"`
+	MinorUnits         int              `json:"minor_units"`
+	Legs               []ConversionLeg  `json:"legs"`
+}
+
+// MissingTarget is a target currency which could not be converted into
+type MissingTarget struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+type MultiConversionResponse struct {
+	From         string             `json:"from"`
+	Amount       decimal.Decimal    `json:"amount"`
+	RoundingMode string             `json:"rounding_mode"`
+	At           string             `json:"at,omitempty"`
+	Conversions  []TargetConversion `json:"conversions"`
+	Missing      []MissingTarget    `json:"missing"`
 }
@@ -1,30 +1,70 @@
 package dto
 
+import (
+	"currency-converter/decimal"
+	"currency-converter/utils"
+)
+
+// CurrencySortFields are the columns GET /currencies can sort by, "-" in front sorts descending
+var CurrencySortFields = []string{"id", "code", "name", "created_at", "updated_at"}
+
+// CurrencyFilter selects one page of currencies, nil and empty fields are not filtered on
+type CurrencyFilter struct {
+	IsActive   *bool
+	CodePrefix string
+	Name       string // case insensitive substring of the name
+	Sort       string
+	Cursor     *utils.Cursor
+	Limit      int
+}
+
 type CurrencyRequest struct {
-	Code   string `json:"code" binding:"required,len=3"`
-	Name   string `json:"name" binding:"required"`
-	Symbol string `json:"symbol" binding:"required len=1"`
+	Code                  string           `json:"code" binding:"required,len=3"`
+	Name                  string           `json:"name" binding:"required"`
+	Symbol                string           `json:"symbol" binding:"required"`
+	NumericCode           string           `json:"numeric_code" binding:"omitempty,len=3,numeric"`
+	MinorUnits            *int             `json:"minor_units" binding:"omitempty,min=0,max=4"`
+	CashRoundingIncrement *decimal.Decimal `json:"cash_rounding_increment"`
 }
 
 type CurrencyResponse struct {
-	ID        int    `json:"id"`
-	Code      string `json:"code"`
-	Name      string `json:"name"`
-	Symbol    string `json:"symbol"`
-	IsActive  bool   `json:"is_active"`
-	Deleted   bool   `json:"deleted"`
-	DeletedAt string `json:"deleted_at"`
-	UpdatedAt string `json:"updated_at"`
-	CreatedAt string `json:"created_at"`
+	ID                    int              `json:"id"`
+	Code                  string           `json:"code"`
+	Name                  string           `json:"name"`
+	Symbol                string           `json:"symbol"`
+	NumericCode           string           `json:"numeric_code"`
+	MinorUnits            *int             `json:"minor_units"`
+	CashRoundingIncrement *decimal.Decimal `json:"cash_rounding_increment"`
+	IsActive              bool             `json:"is_active"`
+	Deleted               bool             `json:"deleted"`
+	DeletedAt             string           `json:"deleted_at"`
+	UpdatedAt             string           `json:"updated_at"`
+	CreatedAt             string           `json:"created_at"`
+	CreatedBy             *int             `json:"created_by"`
+	UpdatedBy             *int             `json:"updated_by"`
+}
+
+// CurrencySeedResult lists the codes touched by a seed, by what happened to them
+type CurrencySeedResult struct {
+	Inserted  []string
+	Updated   []string // existing currencies which were missing ISO details
+	Unchanged []string
 }
 
 type CurrencyListResponse struct {
 	Currencies []CurrencyResponse `json:"currencies"`
 }
 
-//  use of map , reseach on it
-type CurrencyUpdateRequest struct {    
-	Name     *string `json:"name"`
-	Symbol   *string `json:"symbol"`
-	IsActive *bool   `json:"is_active"`
+// use of map , reseach on it
+type CurrencyUpdateRequest struct {
+	Name                  *string          `json:"name"`
+	Symbol                *string          `json:"symbol"`
+	MinorUnits            *int             `json:"minor_units" binding:"omitempty,min=0,max=4"`
+	CashRoundingIncrement *decimal.Decimal `json:"cash_rounding_increment"`
+	IsActive              *bool            `json:"is_active"`
+	RestoreRates          bool             `json:"restore_rates" gorm:"-"` // with is_active true, also reactivate the rates deactivated along with the currency
+}
+
+type CurrencyRestoreRequest struct {
+	RestoreRates bool `json:"restore_rates"` // also restore the rates deleted along with the currency
 }
@@ -1,21 +1,57 @@
 package dto
 
+import (
+	"currency-converter/decimal"
+	"currency-converter/utils"
+	"time"
+)
+
+// ExchangeRateSortFields are the columns GET /exchange-rates can sort by, "-" in front sorts descending
+var ExchangeRateSortFields = []string{"id", "created_at", "updated_at"}
+
+// ExchangeRateFilter selects one page of exchange rates, nil and empty fields are not filtered on
+type ExchangeRateFilter struct {
+	FromCode     string
+	ToCode       string
+	IsActive     *bool
+	UpdatedSince *time.Time
+	Sort         string
+	Cursor       *utils.Cursor
+	Limit        int
+}
+
+// ExchangeRateRequest names each currency by ISO code or by id, the code wins if both are given
 type ExchangeRateRequest struct {
-	FromCurrencyID int     `json:"from_currency_id" binding:"required"`
-	ToCurrencyID   int     `json:"to_currency_id" binding:"required"`
-	Rate           float64 `json:"rate" binding:"required"`
+	FromCurrency   string          `json:"from_currency" binding:"omitempty,len=3"`
+	ToCurrency     string          `json:"to_currency" binding:"omitempty,len=3"`
+	FromCurrencyID int             `json:"from_currency_id"`
+	ToCurrencyID   int             `json:"to_currency_id"`
+	Rate           decimal.Decimal `json:"rate"`
 }
 
 type ExchangeRateResponse struct {
-	ID             int     `json:"id"`
-	FromCurrencyID int     `json:"from_currency_id"`
-	ToCurrencyID   int     `json:"to_currency_id"`
-	Rate           float64 `json:"rate"`
-	IsActive       bool    `json:"is_active"`
-	Deleted        bool    `json:"deleted"`
-	DeletedAt      string  `json:"deleted_at"`
-	UpdatedAt      string  `json:"updated_at"`
-	CreatedAt      string  `json:"created_at"`
+	ID             int             `json:"id"`
+	FromCurrencyID int             `json:"from_currency_id"`
+	ToCurrencyID   int             `json:"to_currency_id"`
+	FromCurrency   CurrencySummary `json:"from_currency"`
+	ToCurrency     CurrencySummary `json:"to_currency"`
+	Rate           decimal.Decimal `json:"rate"`
+	Source         string          `json:"source"`
+	Quotes         []RateQuote     `json:"quotes,omitempty"`
+	IsActive       bool            `json:"is_active"`
+	Deleted        bool            `json:"deleted"`
+	DeletedAt      string          `json:"deleted_at"`
+	UpdatedAt      string          `json:"updated_at"`
+	CreatedAt      string          `json:"created_at"`
+	CreatedBy      *int            `json:"created_by"`
+	UpdatedBy      *int            `json:"updated_by"`
+}
+
+type CurrencySummary struct {
+	ID     int    `json:"id"`
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
 }
 
 type ExchangeRateListResponse struct {
@@ -23,12 +59,57 @@ type ExchangeRateListResponse struct {
 }
 
 type ExchangeRateUpdateRequest struct {
-	Rate     *float64 `json:"rate"`
-	IsActive *bool    `json:"is_active"`
+	Rate     *decimal.Decimal `json:"rate"`
+	IsActive *bool            `json:"is_active"`
+}
+
+type SyncResult struct {
+	Base    string
+	Source  string // provider the quotes came from
+	Updated []string
+	Skipped []SkippedCurrency
+}
+
+type SkippedCurrency struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// RateQuote is a provider quote which took part in a consensus rate
+type RateQuote struct {
+	Source   string          `json:"source"`
+	Rate     decimal.Decimal `json:"rate"`
+	Accepted bool            `json:"accepted"`
+}
+
+type QuarantinedRateResponse struct {
+	ID             int              `json:"id"`
+	ExchangeRateID *int             `json:"exchange_rate_id"`
+	FromCurrencyID int              `json:"from_currency_id"`
+	ToCurrencyID   int              `json:"to_currency_id"`
+	Rate           decimal.Decimal  `json:"rate"`
+	PreviousRate   *decimal.Decimal `json:"previous_rate"`
+	Source         string           `json:"source"`
+	Quotes         []RateQuote      `json:"quotes,omitempty"`
+	Reason         string           `json:"reason"`
+	Status         string           `json:"status"`
+	ReviewedBy     *int             `json:"reviewed_by,omitempty"`
+	ReviewedAt     string           `json:"reviewed_at,omitempty"`
+	CreatedAt      string           `json:"created_at"`
 }
 
-type ExchangeRateExternalResponse struct {
-	Result          string             `json:"result"`
-	BaseCode        string             `json:"base_code"`
-	ConversionRates map[string]float64 `json:"conversion_rates"`
+type ExchangeRateChangeResponse struct {
+	ID             int              `json:"id"`
+	Action         string           `json:"action"`
+	ExchangeRateID *int             `json:"exchange_rate_id"` // null for a create until it is approved
+	FromCurrencyID *int             `json:"from_currency_id,omitempty"`
+	ToCurrencyID   *int             `json:"to_currency_id,omitempty"`
+	Rate           *decimal.Decimal `json:"rate,omitempty"`
+	IsActive       *bool            `json:"is_active,omitempty"`
+	Status         string           `json:"status"`
+	ProposedBy     int              `json:"proposed_by"`
+	ReviewedBy     *int             `json:"reviewed_by,omitempty"`
+	ReviewedAt     string           `json:"reviewed_at,omitempty"`
+	QuarantineID   *int             `json:"quarantine_id,omitempty"`
+	CreatedAt      string           `json:"created_at"`
 }
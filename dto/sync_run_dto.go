@@ -0,0 +1,9 @@
+package dto
+
+type SyncRunResponse struct {
+	BaseCode   string `json:"base_code"`
+	LastRunAt  string `json:"last_run_at"`
+	DurationMs int64  `json:"duration_ms"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
@@ -1,32 +1,44 @@
 package middleware
 
 import (
+	"context"
+	"currency-converter/models"
 	"currency-converter/security"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// type userCtxKeyType struct{}
+type userCtxKeyType struct{}
 
-// var userCtxKey = userCtxKeyType{}
+var userCtxKey = userCtxKeyType{}
+
+const apiKeyHeader = "X-API-Key"
+
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*security.RequestClaims, error)
+}
 
 type AuthMiddleware struct {
 	tokenSvc *security.TokenService
+	apiKeys  APIKeyAuthenticator
 }
 
-func NewAuthMiddleware(tokenSvc *security.TokenService) *AuthMiddleware {
+func NewAuthMiddleware(tokenSvc *security.TokenService, apiKeys APIKeyAuthenticator) *AuthMiddleware {
 	return &AuthMiddleware{
 		tokenSvc: tokenSvc,
+		apiKeys:  apiKeys,
 	}
 }
 
+// Handle accepts either a Bearer JWT or an X-API-Key header
 func (a *AuthMiddleware) Handle() gin.HandlerFunc {
 	fn := func(c *gin.Context) {
 
-		token, err := a.extractBearerToken(c)
+		claims, err := a.authenticate(c)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": err.Error(),
@@ -35,30 +47,78 @@ func (a *AuthMiddleware) Handle() gin.HandlerFunc {
 			return
 		}
 
-		claims, err := a.tokenSvc.ValidateAccessToken(token)
-		if err != nil {
+		// Extra safety checks (optional but recommended)
+		if claims.UserID == 0 {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": err.Error(),
+				"error": "user is unauhorised",
 			})
 			c.Abort()
 			return
 		}
 
-		// Extra safety checks (optional but recommended)
-		if claims.UserID == 0 {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "user is unauhorised",
+		// API keys are limited to their scopes on top of the owner's role
+		if claims.APIKeyID != 0 && !hasScope(claims.Scopes, requiredScope(c)) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "API key is not allowed to " + requiredScope(c),
 			})
 			c.Abort()
 			return
 		}
-		// c.Set(userCtxKey, claims)
+		// services only see the context.Context, so the caller goes in there too
+		c.Set(userCtxKey, claims)
+		c.Request = c.Request.WithContext(security.WithClaims(c.Request.Context(), claims))
 
 		c.Next()
 	}
 	return gin.HandlerFunc(fn)
 }
 
+// RequireUserToken rejects callers authenticated with an API key,
+// for routes like key management which need a real login
+func RequireUserToken() gin.HandlerFunc {
+	fn := func(c *gin.Context) {
+		claims, ok := Claims(c)
+		if !ok || claims.APIKeyID != 0 {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "this action requires a user token, not an API key",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+	return gin.HandlerFunc(fn)
+}
+
+func (a *AuthMiddleware) authenticate(c *gin.Context) (*security.RequestClaims, error) {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		return a.apiKeys.AuthenticateAPIKey(c.Request.Context(), key)
+	}
+
+	token, err := a.extractBearerToken(c)
+	if err != nil {
+		return nil, err
+	}
+	return a.tokenSvc.ValidateAccessToken(c.Request.Context(), token)
+}
+
+// requiredScope maps a route to the API key scope it needs
+func requiredScope(c *gin.Context) string {
+	if strings.HasPrefix(c.FullPath(), "/convert") {
+		return models.ScopeConvert
+	}
+	if c.Request.Method != http.MethodGet {
+		return models.ScopeWrite
+	}
+	return models.ScopeRead
+}
+
+// hasScope reports whether the scopes allow the action, no scopes means unrestricted
+func hasScope(scopes []string, scope string) bool {
+	return len(scopes) == 0 || slices.Contains(scopes, scope)
+}
+
 func (*AuthMiddleware) extractBearerToken(c *gin.Context) (string, error) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
@@ -72,3 +132,13 @@ func (*AuthMiddleware) extractBearerToken(c *gin.Context) (string, error) {
 
 	return parts[1], nil
 }
+
+// Claims returns the claims of the authenticated caller stored by Handle
+func Claims(c *gin.Context) (*security.RequestClaims, bool) {
+	v, ok := c.Get(userCtxKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*security.RequestClaims)
+	return claims, ok
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRoles only lets callers with one of the given roles through,
+// it must run after AuthMiddleware.Handle
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	fn := func(c *gin.Context) {
+		claims, ok := Claims(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "user is unauthorised",
+			})
+			c.Abort()
+			return
+		}
+
+		if !slices.Contains(roles, claims.Role) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "user does not have permission for this action",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+	return gin.HandlerFunc(fn)
+}
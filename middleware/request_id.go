@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"currency-converter/utils"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// RequestID keeps the caller's X-Request-ID, or makes one up, echoes it back
+// and stores it in the request context for the audit log
+func RequestID() gin.HandlerFunc {
+	fn := func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+
+		c.Header(requestIDHeader, requestID)
+		c.Request = c.Request.WithContext(utils.WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+	return gin.HandlerFunc(fn)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
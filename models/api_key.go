@@ -0,0 +1,66 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// API key scopes, a key without scopes can do whatever the role of its owner allows
+const (
+	ScopeConvert = "convert" // /convert and its variants
+	ScopeRead    = "read"    // every other GET
+	ScopeWrite   = "write"   // every other method
+)
+
+// APIKey lets a service call the API on behalf of its owner. The key is handed out
+// once as "<prefix>.<secret>", the prefix finds the row and the secret is stored
+// as an argon2id hash like a password
+type APIKey struct {
+	ID         int        `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID     int        `gorm:"column:user_id;not null;index"`
+	Name       string     `gorm:"column:name;not null"`
+	Prefix     string     `gorm:"column:prefix;not null;uniqueIndex"`
+	KeyHash    string     `gorm:"column:key_hash;not null"`
+	Scopes     Scopes     `gorm:"column:scopes;type:jsonb"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime:true"`
+}
+
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeConvert, ScopeRead, ScopeWrite:
+		return true
+	}
+	return false
+}
+
+// Scopes is stored as a jsonb column
+type Scopes []string
+
+func (s Scopes) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (s *Scopes) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case []byte:
+		return json.Unmarshal(v, s)
+	}
+	return fmt.Errorf("cannot scan %T into Scopes", src)
+}
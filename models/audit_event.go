@@ -0,0 +1,76 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// audited actions
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditSync    = "sync"
+	AuditRestore = "restore"
+	AuditApprove = "approve"
+	AuditReject  = "reject"
+)
+
+// audited entities
+const (
+	AuditEntityCurrency        = "currency"
+	AuditEntityExchangeRate    = "exchange_rate"
+	AuditEntityQuarantinedRate = "quarantined_rate"
+)
+
+// AuditEvent records one mutation, Before and After are snapshots of the row
+// keyed by column and Changes holds the columns which differ as {"old", "new"}
+type AuditEvent struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	ActorID   *int      `gorm:"column:actor_id;index"` // nil for background work like the scheduled sync
+	Action    string    `gorm:"column:action;not null"`
+	Entity    string    `gorm:"column:entity;not null;index:idx_audit_events_entity"`
+	EntityID  int       `gorm:"column:entity_id;not null;index:idx_audit_events_entity"`
+	Before    JSONMap   `gorm:"column:before;type:jsonb"`
+	After     JSONMap   `gorm:"column:after;type:jsonb"`
+	Changes   JSONMap   `gorm:"column:changes;type:jsonb"`
+	RequestID string    `gorm:"column:request_id;index"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime:true;index"`
+}
+
+// JSONMap is stored as a jsonb column
+type JSONMap map[string]any
+
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan keeps numbers as json.Number so that rates read back exactly
+func (m *JSONMap) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into JSONMap", src)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(m)
+}
@@ -1,19 +1,26 @@
 package models
 
 import (
+	"currency-converter/decimal"
 	"time"
 )
 
 type Currency struct {
-	ID        int       `gorm:"column:id;primaryKey;autoIncrement"`
-	Code      string    `gorm:"column:code;not null;size:3;uppercase"`
-	Name      string    `gorm:"column:name;not null"`
-	Symbol    string    `gorm:"column:symbol;not null"`
-	IsActive  bool      `gorm:"column:is_active;default:true"`
-	Deleted   bool      `gorm:"column:deleted;default:false; not null"`
-	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime:true"`
-	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime:false"`
-	DeletedAt time.Time `gorm:"column:deleted_at;autoUpdateTime:false"`
+	ID                    int              `gorm:"column:id;primaryKey;autoIncrement"`
+	Code                  string           `gorm:"column:code;not null;size:3;uppercase"`
+	Name                  string           `gorm:"column:name;not null"`
+	Symbol                string           `gorm:"column:symbol;not null"`
+	NumericCode           string           `gorm:"column:numeric_code;size:3"`
+	IsActive              bool             `gorm:"column:is_active;default:true"`
+	MinorUnits            *int             `gorm:"column:minor_units"`                                 // ISO 4217 exponent, 0 for JPY and 3 for BHD, nil uses ROUNDING_SCALE
+	CashRoundingIncrement *decimal.Decimal `gorm:"column:cash_rounding_increment;type:numeric(20,10)"` // e.g. 0.05 for CHF, nil when cash is not rounded
+	Deleted               bool             `gorm:"column:deleted;default:false; not null"`
+	CreatedAt             time.Time        `gorm:"column:created_at;autoCreateTime:true"`
+	UpdatedAt             time.Time        `gorm:"column:updated_at;autoUpdateTime:false"`
+	DeletedAt             time.Time        `gorm:"column:deleted_at;autoUpdateTime:false"`
+	DeactivatedAt         time.Time        `gorm:"column:deactivated_at;autoUpdateTime:false"` // when it was last set inactive
+	CreatedBy             *int             `gorm:"column:created_by"`                          // nil when not created by a user, e.g. by a seed
+	UpdatedBy             *int             `gorm:"column:updated_by"`
 }
 
 // Note: The partial unique index needs to be created via a migration
@@ -1,15 +1,31 @@
 package models
 
-import "time"
+import (
+	"currency-converter/decimal"
+	"time"
+)
 
 type ExchangeRate struct {
-	ID             int       `gorm:"column:id;primaryKey;autoIncrement"`
-	FromCurrencyID int       `gorm:"column:from_currency_id;not null;reference:currencies(id)"`
-	ToCurrencyID   int       `gorm:"column:to_currency_id;not null;reference:currencies(id)"`
-	Rate           float64   `gorm:"column:rate;not null"`
-	IsActive       bool      `gorm:"column:is_active;default:true"`
-	Deleted        bool      `gorm:"column:deleted;default:false;not null"`
-	CreatedAt      time.Time `gorm:"column:created_at;autoCreateTime:true"`
-	UpdatedAt      time.Time `gorm:"column:updated_at;autoUpdateTime:false"`
-	DeletedAt      time.Time `gorm:"column:deleted_at;autoUpdateTime:false"`
+	ID             int             `gorm:"column:id;primaryKey;autoIncrement"`
+	FromCurrencyID int             `gorm:"column:from_currency_id;not null"` // foreign keys and checks are in db/migrations
+	ToCurrencyID   int             `gorm:"column:to_currency_id;not null"`
+	Rate           decimal.Decimal `gorm:"column:rate;type:numeric(20,10);not null"`
+	Source         string          `gorm:"column:source;not null;default:manual"`
+	Quotes         RateQuotes      `gorm:"column:quotes;type:jsonb"` // contributing quotes of a consensus rate
+	IsActive       bool            `gorm:"column:is_active;default:true"`
+	Deleted        bool            `gorm:"column:deleted;default:false;not null"`
+	CreatedAt      time.Time       `gorm:"column:created_at;autoCreateTime:true"`
+	UpdatedAt      time.Time       `gorm:"column:updated_at;autoUpdateTime:false"`
+	DeletedAt      time.Time       `gorm:"column:deleted_at;autoUpdateTime:false"`
+	DeactivatedAt  time.Time       `gorm:"column:deactivated_at;autoUpdateTime:false"` // the currency's, when deactivated along with it
+	CreatedBy      *int            `gorm:"column:created_by"`                          // nil when written by the scheduled sync
+	UpdatedBy      *int            `gorm:"column:updated_by"`
+
+	// filled by the repository's join with currencies, not columns of exchange_rates
+	FromCurrencyCode   string `gorm:"column:from_currency_code;->;-:migration"`
+	FromCurrencyName   string `gorm:"column:from_currency_name;->;-:migration"`
+	FromCurrencySymbol string `gorm:"column:from_currency_symbol;->;-:migration"`
+	ToCurrencyCode     string `gorm:"column:to_currency_code;->;-:migration"`
+	ToCurrencyName     string `gorm:"column:to_currency_name;->;-:migration"`
+	ToCurrencySymbol   string `gorm:"column:to_currency_symbol;->;-:migration"`
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"currency-converter/decimal"
+	"time"
+)
+
+// review states of a proposed rate change
+const (
+	ChangePending  = "pending"
+	ChangeApproved = "approved"
+	ChangeRejected = "rejected"
+)
+
+// what a proposed change does to exchange_rates
+const (
+	ChangeActionCreate = "create"
+	ChangeActionUpdate = "update"
+	ChangeActionDelete = "delete"
+)
+
+// ExchangeRateChange is a manual change proposed by one user (maker)
+// which only reaches exchange_rates once a different user (checker) approves it.
+// A create names the pair and gets its ExchangeRateID once approved
+type ExchangeRateChange struct {
+	ID             int              `gorm:"column:id;primaryKey;autoIncrement"`
+	Action         string           `gorm:"column:action;not null;default:update"`
+	ExchangeRateID *int             `gorm:"column:exchange_rate_id;index"`
+	FromCurrencyID *int             `gorm:"column:from_currency_id"`
+	ToCurrencyID   *int             `gorm:"column:to_currency_id"`
+	Rate           *decimal.Decimal `gorm:"column:rate;type:numeric(20,10)"`
+	IsActive       *bool            `gorm:"column:is_active"`
+	Status         string           `gorm:"column:status;not null;default:pending;index"`
+	ProposedBy     int              `gorm:"column:proposed_by;not null"`
+	ReviewedBy     *int             `gorm:"column:reviewed_by"`
+	ReviewedAt     *time.Time       `gorm:"column:reviewed_at"`
+	QuarantineID   *int             `gorm:"column:quarantine_id"` // set when the approved rate tripped the rate guard
+	CreatedAt      time.Time        `gorm:"column:created_at;autoCreateTime:true"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"currency-converter/decimal"
+	"time"
+)
+
+// sources of a rate which are not a provider, synced rates carry the provider name
+const (
+	RateSourceBaseline = "baseline" // rates which existed before history was recorded
+	RateSourceManual   = "manual"
+)
+
+// ExchangeRateHistory is append only, every rate an exchange rate ever had
+// is kept here together with the moment it became effective. A retired entry is a
+// tombstone, the rate was deleted or deactivated at that moment and can't be used until
+// a later entry brings it back
+type ExchangeRateHistory struct {
+	ID             int             `gorm:"column:id;primaryKey;autoIncrement"`
+	ExchangeRateID int             `gorm:"column:exchange_rate_id;not null;index"`
+	FromCurrencyID int             `gorm:"column:from_currency_id;not null"`
+	ToCurrencyID   int             `gorm:"column:to_currency_id;not null"`
+	Rate           decimal.Decimal `gorm:"column:rate;type:numeric(20,10);not null"`
+	Source         string          `gorm:"column:source;not null"`
+	Quotes         RateQuotes      `gorm:"column:quotes;type:jsonb"`
+	Retired        bool            `gorm:"column:retired;not null;default:false"`
+	EffectiveAt    time.Time       `gorm:"column:effective_at;not null"`
+	CreatedAt      time.Time       `gorm:"column:created_at;autoCreateTime:true"`
+}
+
+// AsExchangeRate converts the entry into the exchange rate it was at the time
+func (h ExchangeRateHistory) AsExchangeRate() ExchangeRate {
+	return ExchangeRate{
+		ID:             h.ExchangeRateID,
+		FromCurrencyID: h.FromCurrencyID,
+		ToCurrencyID:   h.ToCurrencyID,
+		Rate:           h.Rate,
+		Source:         h.Source,
+		Quotes:         h.Quotes,
+		IsActive:       !h.Retired,
+		UpdatedAt:      h.EffectiveAt,
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"currency-converter/decimal"
+	"time"
+)
+
+// review states of a quarantined rate
+const (
+	QuarantinePending  = "pending"
+	QuarantineApproved = "approved"
+	QuarantineRejected = "rejected"
+	// a newer quarantined rate for the same pair replaced it before it was reviewed
+	QuarantineSuperseded = "superseded"
+)
+
+// QuarantinedRate is a suspicious rate change held back from exchange_rates
+// until an admin approves or rejects it, the previous rate stays active meanwhile
+type QuarantinedRate struct {
+	ID             int              `gorm:"column:id;primaryKey;autoIncrement"`
+	ExchangeRateID *int             `gorm:"column:exchange_rate_id"` // nil when the pair had no rate yet
+	FromCurrencyID int              `gorm:"column:from_currency_id;not null"`
+	ToCurrencyID   int              `gorm:"column:to_currency_id;not null"`
+	Rate           decimal.Decimal  `gorm:"column:rate;type:numeric(20,10);not null"`
+	PreviousRate   *decimal.Decimal `gorm:"column:previous_rate;type:numeric(20,10)"`
+	Source         string           `gorm:"column:source;not null"`
+	Quotes         RateQuotes       `gorm:"column:quotes;type:jsonb"`
+	Reason         string           `gorm:"column:reason;not null"`
+	Status         string           `gorm:"column:status;not null;default:pending;index"`
+	ReviewedBy     *int             `gorm:"column:reviewed_by"`
+	ReviewedAt     *time.Time       `gorm:"column:reviewed_at"`
+	CreatedAt      time.Time        `gorm:"column:created_at;autoCreateTime:true"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"currency-converter/decimal"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// RateQuote is one provider quote which contributed to a consensus rate
+type RateQuote struct {
+	Source   string          `json:"source"`
+	Rate     decimal.Decimal `json:"rate"`
+	Accepted bool            `json:"accepted"`
+}
+
+// RateQuotes is stored as a jsonb column
+type RateQuotes []RateQuote
+
+func (q RateQuotes) Value() (driver.Value, error) {
+	if q == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(q)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (q *RateQuotes) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*q = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), q)
+	case []byte:
+		return json.Unmarshal(v, q)
+	}
+	return fmt.Errorf("cannot scan %T into RateQuotes", src)
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RefreshToken is one link of a rotation chain, every refresh rotates the token
+// and the new one keeps the FamilyID of the login it descends from.
+// Only the sha256 of the token is stored
+type RefreshToken struct {
+	ID        int        `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID    int        `gorm:"column:user_id;not null;index"`
+	FamilyID  string     `gorm:"column:family_id;not null;index"`
+	TokenHash string     `gorm:"column:token_hash;not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	RotatedAt *time.Time `gorm:"column:rotated_at"`
+	RevokedAt *time.Time `gorm:"column:revoked_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime:true"`
+}
+
+// RevokedToken is an entry of the access token revocation list, TokenID is either
+// the jti of a single access token or a session (refresh token family) id.
+// Entries are only needed until every access token they cover has expired
+type RevokedToken struct {
+	TokenID   string    `gorm:"column:token_id;primaryKey"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null;index"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime:true"`
+}
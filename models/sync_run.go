@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// outcomes of a sync run
+const (
+	SyncStatusSuccess = "success"
+	SyncStatusFailed  = "failed"
+)
+
+// SyncLockKey is the postgres advisory lock held while syncing, by the scheduler of
+// every replica and by a manual sync
+const SyncLockKey int64 = 0x63637379_6e6300 // "ccsync"
+
+// SyncRun keeps the last sync of each base currency, scheduled or manual
+type SyncRun struct {
+	BaseCode   string    `gorm:"column:base_code;primaryKey;size:3"`
+	LastRunAt  time.Time `gorm:"column:last_run_at;not null"`
+	DurationMs int64     `gorm:"column:duration_ms;not null"`
+	Status     string    `gorm:"column:status;not null"`
+	Error      string    `gorm:"column:error"`
+}
@@ -2,10 +2,27 @@ package models
 
 import "time"
 
+// user roles, admins can do everything, rate managers can change currencies
+// and exchange rates, viewers can only read and convert
+const (
+	RoleAdmin       = "admin"
+	RoleRateManager = "rate_manager"
+	RoleViewer      = "viewer"
+)
+
 type User struct {
 	ID           int       `gorm:"column:id;primaryKey;autoIncrement"`
 	Email        string    `gorm:"column:email;uniqueIndex;not null"`
 	PasswordHash string    `gorm:"column:password_hash;not null"`
+	Role         string    `gorm:"column:role;not null;default:viewer"`
 	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime:true"`
 	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime:false"`
 }
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleRateManager, RoleViewer:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt
+	BaseDelay  time.Duration // delay before the first retry, doubled on every retry
+	MaxDelay   time.Duration
+}
+
+type BreakerPolicy struct {
+	FailureThreshold int // consecutive failed fetches which open the breaker
+	Cooldown         time.Duration
+}
+
+type chainLink struct {
+	provider Provider
+	breaker  *breaker
+}
+
+// Chain tries its providers in order, retrying each with exponential backoff,
+// and skips providers whose circuit breaker is open
+type Chain struct {
+	links []chainLink
+	retry RetryPolicy
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewChain(providers []Provider, retry RetryPolicy, breakerPolicy BreakerPolicy) *Chain {
+	links := make([]chainLink, 0, len(providers))
+	for _, p := range providers {
+		links = append(links, chainLink{
+			provider: p,
+			breaker:  newBreaker(breakerPolicy.FailureThreshold, breakerPolicy.Cooldown, time.Now),
+		})
+	}
+	return &Chain{
+		links: links,
+		retry: retry,
+		sleep: sleepContext,
+	}
+}
+
+func (c *Chain) Name() string {
+	names := make([]string, 0, len(c.links))
+	for _, link := range c.links {
+		names = append(names, link.provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func (c *Chain) FetchRates(ctx context.Context, base string) ([]Quote, error) {
+	var errs []error
+	unsupported := 0
+
+	for _, link := range c.links {
+		name := link.provider.Name()
+
+		if !link.breaker.Allow() {
+			errs = append(errs, fmt.Errorf("%s: circuit breaker open", name))
+			continue
+		}
+
+		quotes, err := c.fetchWithRetry(ctx, link.provider, base)
+		switch {
+		case err == nil:
+			link.breaker.Success()
+			return quotes, nil
+		case errors.Is(err, ErrUnsupportedBase):
+			// not a provider failure, the next provider may support the base
+			link.breaker.Release()
+			unsupported++
+		case ctx.Err() != nil:
+			link.breaker.Release()
+			return nil, ctx.Err()
+		default:
+			link.breaker.Failure()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+
+	if unsupported == len(c.links) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedBase, base)
+	}
+	return nil, fmt.Errorf("all exchange rate providers failed: %w", errors.Join(errs...))
+}
+
+func (c *Chain) fetchWithRetry(ctx context.Context, p Provider, base string) ([]Quote, error) {
+	var err error
+	for attempt := 0; attempt <= c.retry.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if sleepErr := c.sleep(ctx, c.backoff(attempt)); sleepErr != nil {
+				return nil, sleepErr
+			}
+		}
+
+		var quotes []Quote
+		quotes, err = p.FetchRates(ctx, base)
+		if err == nil || errors.Is(err, ErrUnsupportedBase) {
+			return quotes, err
+		}
+	}
+	return nil, err
+}
+
+// backoff doubles the delay on every retry, capped at MaxDelay,
+// with jitter on the upper half so replicas don't retry in lockstep
+func (c *Chain) backoff(attempt int) time.Duration {
+	d := c.retry.BaseDelay << (attempt - 1)
+	if d <= 0 || d > c.retry.MaxDelay {
+		d = c.retry.MaxDelay
+	}
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,272 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubAPI stands in for an exchangerate-api style provider, it answers with a 500
+// while failing returns true and with rates for the requested base otherwise
+type stubAPI struct {
+	server  *httptest.Server
+	calls   atomic.Int32
+	failing func(call int) bool
+}
+
+func newStubAPI(t *testing.T, failing func(call int) bool) *stubAPI {
+	t.Helper()
+	s := &stubAPI{failing: failing}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(s.calls.Add(1))
+		if s.failing(call) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		base := strings.TrimPrefix(r.URL.Path, "/")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"result":"success","base_code":"` + base + `","conversion_rates":{"EUR":0.9,"INR":83.1}}`))
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// stubECB stands in for the ECB daily reference rates
+func newStubECB(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2024-06-03">
+			<Cube currency="USD" rate="1.0850"/>
+			<Cube currency="INR" rate="90.10"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func alwaysFail(int) bool { return true }
+
+func neverFail(int) bool { return false }
+
+// newTestChain records the backoff delays instead of sleeping
+func newTestChain(providers []Provider, retry RetryPolicy, breakerPolicy BreakerPolicy) (*Chain, *[]time.Duration) {
+	chain := NewChain(providers, retry, breakerPolicy)
+	var delays []time.Duration
+	chain.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return chain, &delays
+}
+
+func sources(quotes []Quote) map[string]bool {
+	seen := map[string]bool{}
+	for _, q := range quotes {
+		seen[q.Source] = true
+	}
+	return seen
+}
+
+func TestChainRetriesThenSucceeds(t *testing.T) {
+	api := newStubAPI(t, func(call int) bool { return call <= 2 })
+	chain, delays := newTestChain(
+		[]Provider{NewExchangeRateAPIProvider(api.server.URL, api.server.Client())},
+		RetryPolicy{MaxRetries: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second},
+		BreakerPolicy{FailureThreshold: 5, Cooldown: time.Minute},
+	)
+
+	quotes, err := chain.FetchRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("FetchRates: %v", err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("got %d quotes, want 2", len(quotes))
+	}
+	if got := api.calls.Load(); got != 3 {
+		t.Errorf("provider called %d times, want 3", got)
+	}
+	if len(*delays) != 2 {
+		t.Errorf("slept %d times, want 2", len(*delays))
+	}
+}
+
+func TestChainBackoffIsExponentialAndCapped(t *testing.T) {
+	api := newStubAPI(t, alwaysFail)
+	chain, delays := newTestChain(
+		[]Provider{NewExchangeRateAPIProvider(api.server.URL, api.server.Client())},
+		RetryPolicy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second},
+		BreakerPolicy{FailureThreshold: 5, Cooldown: time.Minute},
+	)
+
+	if _, err := chain.FetchRates(context.Background(), "USD"); err == nil {
+		t.Fatal("FetchRates succeeded, want an error")
+	}
+
+	// the jitter keeps every delay in the upper half of 100ms, 200ms, 400ms, 800ms and the 1s cap
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	if len(*delays) != len(want) {
+		t.Fatalf("slept %d times, want %d", len(*delays), len(want))
+	}
+	for i, d := range *delays {
+		if d < want[i]/2 || d >= want[i] {
+			t.Errorf("retry %d slept %v, want within [%v, %v)", i+1, d, want[i]/2, want[i])
+		}
+	}
+}
+
+func TestChainFallsBackToNextProvider(t *testing.T) {
+	api := newStubAPI(t, alwaysFail)
+	ecb := newStubECB(t)
+	chain, _ := newTestChain(
+		[]Provider{
+			NewExchangeRateAPIProvider(api.server.URL, api.server.Client()),
+			NewECBProvider(ecb.URL, ecb.Client()),
+		},
+		RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		BreakerPolicy{FailureThreshold: 5, Cooldown: time.Minute},
+	)
+
+	quotes, err := chain.FetchRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("FetchRates: %v", err)
+	}
+	if got := api.calls.Load(); got != 2 {
+		t.Errorf("first provider called %d times, want 2", got)
+	}
+	// the source stored with the synced rates names the provider which answered
+	if got := sources(quotes); len(got) != 1 || !got["ecb"] {
+		t.Errorf("quote sources %v, want only ecb", got)
+	}
+}
+
+func TestChainBreakerOpensSkipsAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	api := newStubAPI(t, func(int) bool { return !healthy.Load() })
+	ecb := newStubECB(t)
+	chain, _ := newTestChain(
+		[]Provider{
+			NewExchangeRateAPIProvider(api.server.URL, api.server.Client()),
+			NewECBProvider(ecb.URL, ecb.Client()),
+		},
+		RetryPolicy{MaxRetries: 0},
+		BreakerPolicy{FailureThreshold: 2, Cooldown: time.Minute},
+	)
+	now := time.Now()
+	chain.links[0].breaker.now = func() time.Time { return now }
+
+	fetch := func() []Quote {
+		t.Helper()
+		quotes, err := chain.FetchRates(context.Background(), "USD")
+		if err != nil {
+			t.Fatalf("FetchRates: %v", err)
+		}
+		return quotes
+	}
+
+	// two failures open the breaker of the first provider
+	fetch()
+	fetch()
+	if got := api.calls.Load(); got != 2 {
+		t.Fatalf("first provider called %d times, want 2", got)
+	}
+
+	// while open it is skipped without a call
+	if got := sources(fetch()); !got["ecb"] {
+		t.Errorf("quote sources %v while the breaker is open, want ecb", got)
+	}
+	if got := api.calls.Load(); got != 2 {
+		t.Errorf("first provider called %d times while its breaker is open, want 2", got)
+	}
+
+	// after the cooldown a single half open trial goes through and closes the breaker
+	healthy.Store(true)
+	now = now.Add(time.Minute + time.Second)
+	if got := sources(fetch()); !got["exchangerate-api"] {
+		t.Errorf("quote sources %v after recovery, want exchangerate-api", got)
+	}
+	if got := api.calls.Load(); got != 3 {
+		t.Errorf("first provider called %d times after the cooldown, want 3", got)
+	}
+	if !chain.links[0].breaker.Allow() {
+		t.Error("breaker still open after a successful trial")
+	}
+}
+
+func TestChainBreakerReopensOnFailedTrial(t *testing.T) {
+	api := newStubAPI(t, alwaysFail)
+	chain, _ := newTestChain(
+		[]Provider{NewExchangeRateAPIProvider(api.server.URL, api.server.Client())},
+		RetryPolicy{MaxRetries: 0},
+		BreakerPolicy{FailureThreshold: 1, Cooldown: time.Minute},
+	)
+	now := time.Now()
+	chain.links[0].breaker.now = func() time.Time { return now }
+
+	_, _ = chain.FetchRates(context.Background(), "USD")
+	now = now.Add(2 * time.Minute)
+	_, _ = chain.FetchRates(context.Background(), "USD") // the trial fails
+	_, err := chain.FetchRates(context.Background(), "USD")
+
+	if got := api.calls.Load(); got != 2 {
+		t.Errorf("provider called %d times, want 2", got)
+	}
+	if err == nil || !strings.Contains(err.Error(), "circuit breaker open") {
+		t.Errorf("error %v, want the breaker to be open again", err)
+	}
+}
+
+func TestChainAllProvidersFail(t *testing.T) {
+	first := newStubAPI(t, alwaysFail)
+	second := newStubAPI(t, alwaysFail)
+	chain, _ := newTestChain(
+		[]Provider{
+			NewExchangeRateAPIProvider(first.server.URL, first.server.Client()),
+			NewExchangeRateAPIProvider(second.server.URL, second.server.Client()),
+		},
+		RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		BreakerPolicy{FailureThreshold: 5, Cooldown: time.Minute},
+	)
+
+	_, err := chain.FetchRates(context.Background(), "USD")
+	if err == nil || !strings.Contains(err.Error(), "all exchange rate providers failed") {
+		t.Fatalf("error %v, want all providers failed", err)
+	}
+	if first.calls.Load() != 3 || second.calls.Load() != 3 {
+		t.Errorf("providers called %d and %d times, want 3 each", first.calls.Load(), second.calls.Load())
+	}
+}
+
+func TestChainSkipsProviderWithoutBase(t *testing.T) {
+	ecb := newStubECB(t)
+	api := newStubAPI(t, neverFail)
+	chain, _ := newTestChain(
+		[]Provider{
+			NewECBProvider(ecb.URL, ecb.Client()),
+			NewExchangeRateAPIProvider(api.server.URL, api.server.Client()),
+		},
+		RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		BreakerPolicy{FailureThreshold: 1, Cooldown: time.Minute},
+	)
+
+	// ECB doesn't quote GBP, that is no failure, isn't retried and doesn't trip the breaker
+	quotes, err := chain.FetchRates(context.Background(), "GBP")
+	if err != nil {
+		t.Fatalf("FetchRates: %v", err)
+	}
+	if got := sources(quotes); len(got) != 1 || !got["exchangerate-api"] {
+		t.Errorf("quote sources %v, want only exchangerate-api", got)
+	}
+	if !chain.links[0].breaker.Allow() {
+		t.Error("an unsupported base opened the breaker")
+	}
+}
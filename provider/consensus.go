@@ -0,0 +1,174 @@
+package provider
+
+import (
+	"context"
+	"currency-converter/decimal"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// consensus methods
+const (
+	MethodMedian      = "median"
+	MethodTrimmedMean = "trimmed-mean"
+)
+
+const ConsensusSource = "consensus"
+
+type ConsensusPolicy struct {
+	Method          string
+	MaxDeviationBps decimal.Decimal // quotes further than this from the median are discarded
+	TrimPercent     int             // share trimmed from each end for the trimmed mean
+	MinQuotes       int             // accepted quotes a pair needs to be published
+}
+
+// Contribution is one provider quote which took part in a consensus rate
+type Contribution struct {
+	Source   string
+	Rate     decimal.Decimal
+	Accepted bool
+}
+
+// Consensus queries every provider and publishes, per pair, the median or
+// trimmed mean of the quotes which are close enough to the median
+type Consensus struct {
+	providers []Provider
+	policy    ConsensusPolicy
+}
+
+func NewConsensus(providers []Provider, policy ConsensusPolicy) *Consensus {
+	return &Consensus{
+		providers: providers,
+		policy:    policy,
+	}
+}
+
+func (c *Consensus) Name() string {
+	return ConsensusSource
+}
+
+func (c *Consensus) FetchRates(ctx context.Context, base string) ([]Quote, error) {
+	results := make([][]Quote, len(c.providers))
+	errs := make([]error, len(c.providers))
+
+	var wg sync.WaitGroup
+	for i, p := range c.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = p.FetchRates(ctx, base)
+		}()
+	}
+	wg.Wait()
+
+	byPair := make(map[string][]Quote)
+	unsupported := 0
+	for i, quotes := range results {
+		if errs[i] != nil {
+			if errors.Is(errs[i], ErrUnsupportedBase) {
+				unsupported++
+			}
+			continue
+		}
+		for _, quote := range quotes {
+			byPair[quote.Quote] = append(byPair[quote.Quote], quote)
+		}
+	}
+	if unsupported == len(c.providers) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedBase, base)
+	}
+	if len(byPair) == 0 {
+		return nil, fmt.Errorf("no exchange rate provider returned quotes: %w", errors.Join(errs...))
+	}
+
+	consensus := make([]Quote, 0, len(byPair))
+	for code, quotes := range byPair {
+		if quote, ok := c.agree(base, code, quotes); ok {
+			consensus = append(consensus, quote)
+		}
+	}
+	return consensus, nil
+}
+
+// agree rejects the outliers of one pair and combines the rest,
+// ok is false when too few quotes are left
+func (c *Consensus) agree(base, code string, quotes []Quote) (Quote, bool) {
+	rates := make([]decimal.Decimal, 0, len(quotes))
+	for _, quote := range quotes {
+		rates = append(rates, quote.Rate)
+	}
+	mid := median(rates)
+	if mid.Sign() <= 0 {
+		return Quote{}, false
+	}
+
+	var accepted []decimal.Decimal
+	var asOf time.Time
+	contributions := make([]Contribution, 0, len(quotes))
+	for _, quote := range quotes {
+		// deviation in basis points = |rate - median| / median * 10000
+		deviation, _ := quote.Rate.Sub(mid).Abs().Mul(decimal.NewFromInt(10000)).Div(mid)
+		ok := deviation.Cmp(c.policy.MaxDeviationBps) <= 0
+
+		contributions = append(contributions, Contribution{Source: quote.Source, Rate: quote.Rate, Accepted: ok})
+		if ok {
+			accepted = append(accepted, quote.Rate)
+			if quote.AsOf.After(asOf) {
+				asOf = quote.AsOf
+			}
+		}
+	}
+	if len(accepted) < c.policy.MinQuotes || len(accepted) == 0 {
+		return Quote{}, false
+	}
+
+	rate := median(accepted)
+	if c.policy.Method == MethodTrimmedMean {
+		rate = trimmedMean(accepted, c.policy.TrimPercent)
+	}
+
+	return Quote{
+		Base:          base,
+		Quote:         code,
+		Rate:          rate.Round(rateScale, decimal.HalfEven),
+		AsOf:          asOf,
+		Source:        ConsensusSource,
+		Contributions: contributions,
+	}, true
+}
+
+func sorted(values []decimal.Decimal) []decimal.Decimal {
+	out := append([]decimal.Decimal(nil), values...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Cmp(out[j]) < 0 })
+	return out
+}
+
+func median(values []decimal.Decimal) decimal.Decimal {
+	s := sorted(values)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	mid, _ := s[n/2-1].Add(s[n/2]).Div(decimal.NewFromInt(2))
+	return mid
+}
+
+// trimmedMean drops trimPercent of the values from each end, at least one value is kept
+func trimmedMean(values []decimal.Decimal, trimPercent int) decimal.Decimal {
+	s := sorted(values)
+	k := len(s) * trimPercent / 100
+	if 2*k >= len(s) {
+		k = (len(s) - 1) / 2
+	}
+	s = s[k : len(s)-k]
+
+	sum := decimal.Zero()
+	for _, v := range s {
+		sum = sum.Add(v)
+	}
+	mean, _ := sum.Div(decimal.NewFromInt(int64(len(s))))
+	return mean
+}
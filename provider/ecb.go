@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"currency-converter/decimal"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const ecbBase = "EUR"
+
+// ecbEnvelope is the ECB daily reference rate format (eurofxref-daily.xml),
+// every rate is quoted against EUR
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+type ecbProvider struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewECBProvider(url string, httpClient *http.Client) *ecbProvider {
+	return &ecbProvider{
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+func (p *ecbProvider) Name() string {
+	return "ecb"
+}
+
+// FetchRates returns the EUR reference rates, for any other base
+// the rates are crossed through EUR
+func (p *ecbProvider) FetchRates(ctx context.Context, base string) ([]Quote, error) {
+	eurRates, asOf, err := p.fetchEURRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	baseRate := decimal.NewFromInt(1)
+	if base != ecbBase {
+		rate, ok := eurRates[base]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedBase, base)
+		}
+		baseRate = rate
+		eurRates[ecbBase] = decimal.NewFromInt(1)
+	}
+
+	quotes := make([]Quote, 0, len(eurRates))
+	for code, eurRate := range eurRates {
+		if code == base {
+			continue
+		}
+		// base -> code = (EUR -> code) / (EUR -> base)
+		rate, err := eurRate.Div(baseRate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ECB rate for %s: %w", base, err)
+		}
+		quotes = append(quotes, Quote{
+			Base:   base,
+			Quote:  code,
+			Rate:   rate.Round(rateScale, decimal.HalfEven),
+			AsOf:   asOf,
+			Source: p.Name(),
+		})
+	}
+	return quotes, nil
+}
+
+func (p *ecbProvider) fetchEURRates(ctx context.Context) (map[string]decimal.Decimal, time.Time, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error in creating http request: %w", err)
+	}
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error in making http request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("received %d response", resp.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, time.Time{}, fmt.Errorf("error in parsing response: %w", err)
+	}
+	if len(envelope.Cube.Days) == 0 {
+		return nil, time.Time{}, fmt.Errorf("response has no rates")
+	}
+
+	// the daily file has a single day, the historical files are newest first
+	day := envelope.Cube.Days[0]
+	asOf, err := time.Parse(time.DateOnly, day.Time)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid date %q: %w", day.Time, err)
+	}
+
+	rates := make(map[string]decimal.Decimal, len(day.Rates))
+	for _, r := range day.Rates {
+		rate, err := decimal.Parse(r.Rate)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("invalid rate for %s: %w", r.Currency, err)
+		}
+		rates[r.Currency] = rate
+	}
+	return rates, asOf, nil
+}
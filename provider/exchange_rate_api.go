@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"currency-converter/decimal"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// exchangeRateAPIResponse is the format served by exchangerate-api.com style APIs,
+// GET <base url>/<code>
+type exchangeRateAPIResponse struct {
+	Result          string                     `json:"result"`
+	BaseCode        string                     `json:"base_code"`
+	ConversionRates map[string]decimal.Decimal `json:"conversion_rates"`
+}
+
+type exchangeRateAPIProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewExchangeRateAPIProvider(baseURL string, httpClient *http.Client) *exchangeRateAPIProvider {
+	return &exchangeRateAPIProvider{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+	}
+}
+
+func (p *exchangeRateAPIProvider) Name() string {
+	return "exchangerate-api"
+}
+
+func (p *exchangeRateAPIProvider) FetchRates(ctx context.Context, base string) ([]Quote, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/"+base, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating http request: %w", err)
+	}
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error in making http request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received %d response", resp.StatusCode)
+	}
+
+	var apiResponse exchangeRateAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("error in parsing response: %w", err)
+	}
+	if apiResponse.Result != "success" {
+		return nil, fmt.Errorf("unsuccessful result %q", apiResponse.Result)
+	}
+	if apiResponse.BaseCode != base {
+		return nil, fmt.Errorf("returned data for unexpected base code %q", apiResponse.BaseCode)
+	}
+
+	now := time.Now()
+	quotes := make([]Quote, 0, len(apiResponse.ConversionRates))
+	for code, rate := range apiResponse.ConversionRates {
+		quotes = append(quotes, Quote{Base: base, Quote: code, Rate: rate, AsOf: now, Source: p.Name()})
+	}
+	return quotes, nil
+}
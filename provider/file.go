@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"currency-converter/decimal"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileQuote is one row of a rate file, as_of is optional
+type fileQuote struct {
+	Base  string          `json:"base"`
+	Quote string          `json:"quote"`
+	Rate  decimal.Decimal `json:"rate"`
+	AsOf  *time.Time      `json:"as_of"`
+}
+
+// fileProvider reads rates from a local file on every fetch, either
+// CSV with the columns base,quote,rate[,as_of] or a JSON array of
+// {"base": "USD", "quote": "INR", "rate": 83.12, "as_of": "2024-01-31T00:00:00Z"}
+type fileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *fileProvider {
+	return &fileProvider{
+		path: path,
+	}
+}
+
+func (p *fileProvider) Name() string {
+	return "file"
+}
+
+func (p *fileProvider) FetchRates(ctx context.Context, base string) ([]Quote, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("error in reading rate file: %w", err)
+	}
+
+	var rows []fileQuote
+	switch strings.ToLower(filepath.Ext(p.path)) {
+	case ".json":
+		err = json.Unmarshal(data, &rows)
+	case ".csv":
+		rows, err = parseCSVQuotes(string(data))
+	default:
+		return nil, fmt.Errorf("unsupported rate file %q, expected .csv or .json", p.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error in parsing rate file: %w", err)
+	}
+
+	modTime := time.Now()
+	if info, err := os.Stat(p.path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	var quotes []Quote
+	for _, row := range rows {
+		if !strings.EqualFold(row.Base, base) {
+			continue
+		}
+		asOf := modTime
+		if row.AsOf != nil {
+			asOf = *row.AsOf
+		}
+		quotes = append(quotes, Quote{
+			Base:   base,
+			Quote:  strings.ToUpper(row.Quote),
+			Rate:   row.Rate,
+			AsOf:   asOf,
+			Source: p.Name(),
+		})
+	}
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedBase, base)
+	}
+	return quotes, nil
+}
+
+func parseCSVQuotes(data string) ([]fileQuote, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]fileQuote, 0, len(records))
+	for i, record := range records {
+		// optional header
+		if i == 0 && strings.EqualFold(record[0], "base") {
+			continue
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected base,quote,rate", i+1)
+		}
+		rate, err := decimal.Parse(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		row := fileQuote{Base: record[0], Quote: record[1], Rate: rate}
+		if len(record) > 3 && record[3] != "" {
+			asOf, err := time.Parse(time.RFC3339, record[3])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid as_of: %w", i+1, err)
+			}
+			row.AsOf = &asOf
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+	"currency-converter/config"
+	"currency-converter/decimal"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// rateScale matches the scale of the rate columns, derived rates are rounded to it
+const rateScale = 10
+
+var ErrUnsupportedBase = errors.New("base currency not supported by provider")
+
+// Quote is a normalized rate, one unit of Base is worth Rate units of Quote
+type Quote struct {
+	Base   string
+	Quote  string
+	Rate   decimal.Decimal
+	AsOf   time.Time
+	Source string // name of the provider which served the quote
+
+	// Contributions are the provider quotes behind a consensus quote
+	Contributions []Contribution
+}
+
+type Provider interface {
+	Name() string
+	FetchRates(ctx context.Context, base string) ([]Quote, error)
+}
+
+// New builds the providers selected in the config, either as an ordered
+// failover chain or, in consensus mode, queried all together
+func New(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+	providers := make([]Provider, 0, len(cfg.Names))
+	for _, name := range cfg.Names {
+		p, err := newProvider(name, cfg, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+
+	retry := RetryPolicy{
+		MaxRetries: cfg.MaxRetries,
+		BaseDelay:  cfg.RetryBaseDelay,
+		MaxDelay:   cfg.RetryMaxDelay,
+	}
+	breaker := BreakerPolicy{
+		FailureThreshold: cfg.BreakerThreshold,
+		Cooldown:         cfg.BreakerCooldown,
+	}
+
+	if cfg.Mode != config.SyncModeConsensus {
+		return NewChain(providers, retry, breaker), nil
+	}
+
+	// every provider keeps its own retries and breaker
+	guarded := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		guarded = append(guarded, NewChain([]Provider{p}, retry, breaker))
+	}
+	return NewConsensus(guarded, ConsensusPolicy{
+		Method:          cfg.ConsensusMethod,
+		MaxDeviationBps: cfg.ConsensusMaxDeviationBps,
+		TrimPercent:     cfg.ConsensusTrimPercent,
+		MinQuotes:       cfg.ConsensusMinQuotes,
+	}), nil
+}
+
+func newProvider(name string, cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+	switch name {
+	case config.ProviderExchangeRateAPI:
+		return NewExchangeRateAPIProvider(cfg.ExchangeRateAPI, httpClient), nil
+	case config.ProviderECB:
+		return NewECBProvider(cfg.ECBURL, httpClient), nil
+	case config.ProviderFile:
+		return NewFileProvider(cfg.FilePath), nil
+	}
+	return nil, fmt.Errorf("unknown rate provider %q", name)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"currency-converter/security"
+)
+
+// actorID is the user behind the request for the created_by/updated_by columns,
+// nil for writes which are not made by a user such as the scheduled sync
+func actorID(ctx context.Context) *int {
+	userID, ok := security.UserIDFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return &userID
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *apiKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+
+	err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetAllByUser(ctx context.Context, userID int) ([]models.APIKey, error) {
+	var keys []models.APIKey
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke revokes a key of the given user, or of any user if userID is nil
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int, userID *int) error {
+	query := r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	tx := query.Update("revoked_at", time.Now())
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return utils.ErrCodeNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed records a use of the key, at most once per interval to keep
+// busy keys from writing on every request
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int, at time.Time, interval time.Duration) error {
+	return r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-interval)).
+		Update("last_used_at", at).Error
+}
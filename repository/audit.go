@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"encoding/json"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// writeAudit records a mutation of one row, it has to be called with the
+// transaction of the change so that both are committed or neither is.
+// before is nil for a create
+func writeAudit(ctx context.Context, tx *gorm.DB, action string, entity string, entityID int, before any, after any) error {
+	beforeSnapshot, err := snapshot(ctx, tx, before)
+	if err != nil {
+		return err
+	}
+	afterSnapshot, err := snapshot(ctx, tx, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.AuditEvent{
+		ActorID:   actorID(ctx),
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Before:    beforeSnapshot,
+		After:     afterSnapshot,
+		Changes:   diff(beforeSnapshot, afterSnapshot),
+		RequestID: utils.RequestIDFromContext(ctx),
+	}).Error
+}
+
+// snapshot turns a model into a map keyed by column name, as its JSON form
+func snapshot(ctx context.Context, tx *gorm.DB, model any) (models.JSONMap, error) {
+	if model == nil || reflect.ValueOf(model).IsNil() {
+		return nil, nil
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(model))
+	row := make(map[string]any, len(stmt.Schema.Fields))
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" || field.IgnoreMigration { // joined values aren't columns of the entity
+			continue
+		}
+		v, _ := field.ValueOf(ctx, value)
+		row[field.DBName] = v
+	}
+
+	// round trip through JSON so decimals and times are stored the way the API prints them,
+	// UseNumber keeps rates exact instead of going through float64
+	data, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	var m models.JSONMap
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// diff lists the columns whose value changed between the two snapshots
+func diff(before, after models.JSONMap) models.JSONMap {
+	changes := models.JSONMap{}
+	for column, newValue := range after {
+		oldValue, ok := before[column]
+		if ok && sameJSON(oldValue, newValue) {
+			continue
+		}
+		changes[column] = map[string]any{"old": oldValue, "new": newValue}
+	}
+	for column, oldValue := range before {
+		if _, ok := after[column]; !ok {
+			changes[column] = map[string]any{"old": oldValue, "new": nil}
+		}
+	}
+	return changes
+}
+
+func sameJSON(a, b any) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
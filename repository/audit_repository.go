@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/models"
+
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *auditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+// GetAll returns one page of the matching events, newest first, and how many match in total
+func (r *auditRepository) GetAll(ctx context.Context, filter dto.AuditFilter) ([]models.AuditEvent, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuditEvent{})
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	err := query.
+		Order("created_at DESC, id DESC").
+		Limit(filter.PageSize).
+		Offset((filter.Page - 1) * filter.PageSize).
+		Find(&events).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
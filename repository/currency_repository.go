@@ -5,9 +5,11 @@ import (
 	"currency-converter/dto"
 	"currency-converter/models"
 	"currency-converter/utils"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type currencyRepository struct {
@@ -21,10 +23,17 @@ func NewCurrencyRepository(db *gorm.DB) *currencyRepository {
 }
 
 func (r *currencyRepository) Create(ctx context.Context, currency *models.Currency) (*models.Currency, error) {
+	currency.CreatedBy = actorID(ctx)
+	currency.UpdatedBy = currency.CreatedBy
 
-	err := r.db.WithContext(ctx).Create(currency).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(currency).Error; err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, models.AuditCreate, models.AuditEntityCurrency, currency.ID, (*models.Currency)(nil), currency)
+	})
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return currency, nil
 }
@@ -48,33 +57,235 @@ func (r *currencyRepository) GetAll(ctx context.Context) ([]models.Currency, err
 	return currencies, nil
 }
 
-func (r *currencyRepository) Update(ctx context.Context, id int, input dto.CurrencyUpdateRequest) error {
-
-	tx := r.db.WithContext(ctx).
-		Model(&models.Currency{}).
-		Where("id = ?", id).
-		Updates(input).Update("updated_at", time.Now())
+// List returns one page of the non-deleted currencies matching the filter, how many
+// match in total, and the cursor of the next page
+func (r *currencyRepository) List(ctx context.Context, filter dto.CurrencyFilter) ([]models.Currency, int64, string, error) {
+	query := r.db.WithContext(ctx).Model(&models.Currency{}).Where("deleted = ?", false)
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.CodePrefix != "" {
+		query = query.Where("code LIKE ?", filter.CodePrefix+"%")
+	}
+	if filter.Name != "" {
+		query = query.Where("name ILIKE ?", "%"+escapeLike(filter.Name)+"%")
+	}
 
-	if tx.Error != nil {
-		return tx.Error
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, "", err
 	}
 
-	if tx.RowsAffected == 0 {
-		return utils.ErrCodeNotFound
+	query, err := paginate(query, "currencies", dto.CurrencySortFields, filter.Sort, filter.Cursor, filter.Limit)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	var currencies []models.Currency
+	if err := query.Find(&currencies).Error; err != nil {
+		return nil, 0, "", err
 	}
 
-	return nil
+	currencies, next := nextCursor(currencies, filter.Sort, filter.Limit, func(currency models.Currency, column string) (any, int) {
+		switch column {
+		case "code":
+			return currency.Code, currency.ID
+		case "name":
+			return currency.Name, currency.ID
+		case "created_at":
+			return currency.CreatedAt, currency.ID
+		case "updated_at":
+			return currency.UpdatedAt, currency.ID
+		}
+		return nil, currency.ID
+	})
+	return currencies, total, next, nil
+}
+
+// Update changes a currency, deactivating it also deactivates its active rates in the
+// same transaction. The rates get the currency's deactivated_at, so that reactivating it
+// with RestoreRates brings them back, except those whose other currency is inactive or
+// deleted. It returns how many rates were deactivated or reactivated
+func (r *currencyRepository) Update(ctx context.Context, id int, input dto.CurrencyUpdateRequest) (int64, error) {
+	var cascaded int64
+
+	err := r.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		before, err := lockCurrency(db, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates := map[string]any{
+			"updated_at": now,
+			"updated_by": actorID(ctx),
+		}
+		deactivating := input.IsActive != nil && !*input.IsActive && before.IsActive
+		if deactivating {
+			updates["deactivated_at"] = now
+		}
+		tx := db.Model(&models.Currency{}).
+			Where("id = ?", id).
+			Updates(input).Updates(updates)
+
+		if tx.Error != nil {
+			return tx.Error
+		}
+
+		if tx.RowsAffected == 0 {
+			return utils.ErrCodeNotFound
+		}
+
+		var after models.Currency
+		if err := db.First(&after, id).Error; err != nil {
+			return err
+		}
+		if err := writeAudit(ctx, db, models.AuditUpdate, models.AuditEntityCurrency, id, before, &after); err != nil {
+			return err
+		}
+
+		// rates of an inactive currency can't be used
+		if deactivating {
+			cascaded, err = updateCurrencyRates(ctx, db, id, models.AuditUpdate, map[string]any{
+				"is_active":      false,
+				"deactivated_at": now,
+				"updated_at":     now,
+				"updated_by":     actorID(ctx),
+			}, "deleted = ? AND is_active = ?", false, true)
+			return err
+		}
+
+		reactivating := !before.IsActive && after.IsActive
+		if !reactivating || !input.RestoreRates || before.DeactivatedAt.IsZero() {
+			return nil
+		}
+		cascaded, err = updateCurrencyRates(ctx, db, id, models.AuditUpdate, map[string]any{
+			"is_active":      true,
+			"deactivated_at": time.Time{},
+			"updated_at":     now,
+			"updated_by":     actorID(ctx),
+		}, `deleted = ? AND is_active = ? AND deactivated_at = ?
+			AND NOT EXISTS (
+				SELECT 1 FROM currencies c
+				WHERE c.id IN (exchange_rates.from_currency_id, exchange_rates.to_currency_id) AND c.id <> ?
+				AND (c.deleted OR NOT c.is_active)
+			)`, false, false, before.DeactivatedAt, id)
+		return err
+	})
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return cascaded, nil
 }
 
-func (r *currencyRepository) Delete(ctx context.Context, id int) error {
+// Delete soft deletes a currency together with every rate from or to it, in one transaction.
+// The rates get the currency's deleted_at, which is how Restore finds them again.
+// It returns how many rates were deleted
+func (r *currencyRepository) Delete(ctx context.Context, id int) (int64, error) {
+	var deleted int64
 
-	return r.db.WithContext(ctx).
-		Model(&models.Currency{}).
-		Where("id = ?", id).
-		Updates(map[string]any{
+	err := r.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		before, err := lockCurrency(db, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err = db.Model(&models.Currency{}).
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"deleted":    true,
+				"deleted_at": now,
+				"updated_by": actorID(ctx),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		var after models.Currency
+		if err := db.First(&after, id).Error; err != nil {
+			return err
+		}
+		if err := writeAudit(ctx, db, models.AuditDelete, models.AuditEntityCurrency, id, before, &after); err != nil {
+			return err
+		}
+
+		deleted, err = updateCurrencyRates(ctx, db, id, models.AuditDelete, map[string]any{
 			"deleted":    true,
-			"deleted_at": time.Now(),
-		}).Error
+			"deleted_at": now,
+			"updated_by": actorID(ctx),
+		}, "deleted = ?", false)
+		return err
+	})
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return deleted, nil
+}
+
+// Restore undeletes a currency. With restoreRates the rates deleted along with it come back
+// too, except those whose other currency is deleted or inactive or whose pair has a newer rate.
+// It returns the restored currency and how many rates were restored
+func (r *currencyRepository) Restore(ctx context.Context, id int, restoreRates bool) (*models.Currency, int64, error) {
+	var after models.Currency
+	var restored int64
+
+	err := r.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		var before models.Currency
+		err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted = ?", id, true).
+			First(&before).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrCodeNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		err = db.Model(&models.Currency{}).
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"deleted":    false,
+				"deleted_at": time.Time{},
+				"updated_at": time.Now(),
+				"updated_by": actorID(ctx),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := db.First(&after, id).Error; err != nil {
+			return err
+		}
+		if err := writeAudit(ctx, db, models.AuditRestore, models.AuditEntityCurrency, id, &before, &after); err != nil {
+			return err
+		}
+
+		if !restoreRates {
+			return nil
+		}
+		restored, err = updateCurrencyRates(ctx, db, id, models.AuditRestore, map[string]any{
+			"deleted":    false,
+			"deleted_at": time.Time{},
+			"updated_at": time.Now(),
+			"updated_by": actorID(ctx),
+		}, `deleted = ? AND deleted_at = ?
+			AND NOT EXISTS (
+				SELECT 1 FROM currencies c
+				WHERE c.id IN (exchange_rates.from_currency_id, exchange_rates.to_currency_id) AND c.id <> ?
+				AND (c.deleted OR NOT c.is_active)
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM exchange_rates live
+				WHERE live.from_currency_id = exchange_rates.from_currency_id
+				AND live.to_currency_id = exchange_rates.to_currency_id
+				AND live.deleted = ?
+			)`, true, before.DeletedAt, id, false)
+		return err
+	})
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+	return &after, restored, nil
 }
 
 func (r *currencyRepository) GetByCode(ctx context.Context, code string) (models.Currency, error) {
@@ -85,3 +296,127 @@ func (r *currencyRepository) GetByCode(ctx context.Context, code string) (models
 	}
 	return currency, nil
 }
+
+// Seed upserts a catalogue of currencies by code in one transaction. Missing codes are
+// inserted as given, existing ones only get their empty numeric code and minor units
+// filled in, so names, symbols and is_active edited by users are kept and a rerun is a no-op
+func (r *currencyRepository) Seed(ctx context.Context, currencies []models.Currency) (dto.CurrencySeedResult, error) {
+	var result dto.CurrencySeedResult
+
+	err := r.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		for _, seed := range currencies {
+			var existing models.Currency
+			err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("code = ? AND deleted = ?", seed.Code, false).
+				First(&existing).Error
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				currency := seed
+				currency.CreatedBy = actorID(ctx)
+				currency.UpdatedBy = currency.CreatedBy
+				if err := db.Create(&currency).Error; err != nil {
+					return err
+				}
+				// is_active defaults to true, so a false value is skipped by Create
+				if !seed.IsActive {
+					if err := db.Model(&currency).Update("is_active", false).Error; err != nil {
+						return err
+					}
+				}
+				if err := writeAudit(ctx, db, models.AuditCreate, models.AuditEntityCurrency, currency.ID, (*models.Currency)(nil), &currency); err != nil {
+					return err
+				}
+				result.Inserted = append(result.Inserted, seed.Code)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			updates := map[string]any{}
+			if existing.NumericCode == "" && seed.NumericCode != "" {
+				updates["numeric_code"] = seed.NumericCode
+			}
+			if existing.MinorUnits == nil && seed.MinorUnits != nil {
+				updates["minor_units"] = *seed.MinorUnits
+			}
+			if len(updates) == 0 {
+				result.Unchanged = append(result.Unchanged, seed.Code)
+				continue
+			}
+
+			updates["updated_at"] = time.Now()
+			updates["updated_by"] = actorID(ctx)
+			if err := db.Model(&models.Currency{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+
+			var after models.Currency
+			if err := db.First(&after, existing.ID).Error; err != nil {
+				return err
+			}
+			if err := writeAudit(ctx, db, models.AuditUpdate, models.AuditEntityCurrency, existing.ID, &existing, &after); err != nil {
+				return err
+			}
+			result.Updated = append(result.Updated, seed.Code)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.CurrencySeedResult{}, err
+	}
+	return result, nil
+}
+
+// lockCurrency loads a non-deleted currency for update, the audit's before snapshot
+func lockCurrency(tx *gorm.DB, id int) (*models.Currency, error) {
+	var currency models.Currency
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND deleted = ?", id, false).
+		First(&currency).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &currency, nil
+}
+
+// updateCurrencyRates applies updates to the rates from or to a currency which match query,
+// writing a history entry and an audit event for each rate, and returns how many it changed
+func updateCurrencyRates(ctx context.Context, db *gorm.DB, currencyID int, action string, updates map[string]any, query string, args ...any) (int64, error) {
+	var before []models.ExchangeRate
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("(from_currency_id = ? OR to_currency_id = ?)", currencyID, currencyID).
+		Where(query, args...).
+		Order("id").
+		Find(&before).Error
+	if err != nil || len(before) == 0 {
+		return 0, err
+	}
+
+	ids := make([]int, 0, len(before))
+	for _, rate := range before {
+		ids = append(ids, rate.ID)
+	}
+	if err := db.Model(&models.ExchangeRate{}).Where("id IN ?", ids).Updates(updates).Error; err != nil {
+		return 0, err
+	}
+
+	var after []models.ExchangeRate
+	if err := db.Where("id IN ?", ids).Order("id").Find(&after).Error; err != nil {
+		return 0, err
+	}
+	for i := range before {
+		// deleted and deactivated rates get a tombstone, restored ones a live entry again
+		if err := db.Create(newExchangeRateHistory(&after[i])).Error; err != nil {
+			return 0, err
+		}
+		if err := writeAudit(ctx, db, action, models.AuditEntityExchangeRate, before[i].ID, &before[i], &after[i]); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(before)), nil
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"currency-converter/utils"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// translateError turns the constraint violations gorm reports (TranslateError is on)
+// into the utils errors the services map to status codes, other errors pass through
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return utils.ErrCodeConflict
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return utils.ErrCodeInvalidReference
+	case errors.Is(err, gorm.ErrCheckConstraintViolated):
+		return utils.ErrCodeConstraint
+	}
+	return err
+}
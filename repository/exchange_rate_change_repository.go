@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type exchangeRateChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateChangeRepository(db *gorm.DB) *exchangeRateChangeRepository {
+	return &exchangeRateChangeRepository{
+		db: db,
+	}
+}
+
+func (r *exchangeRateChangeRepository) Create(ctx context.Context, change *models.ExchangeRateChange) (*models.ExchangeRateChange, error) {
+	err := r.db.WithContext(ctx).Create(change).Error
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (r *exchangeRateChangeRepository) GetByID(ctx context.Context, id int) (*models.ExchangeRateChange, error) {
+	var change models.ExchangeRateChange
+
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&change).Error
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// GetAll returns the changes with the given status, all of them if status is empty
+func (r *exchangeRateChangeRepository) GetAll(ctx context.Context, status string) ([]models.ExchangeRateChange, error) {
+	var changes []models.ExchangeRateChange
+
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Review moves a change from one status to another and records the reviewer,
+// ErrCodeStateChanged means it does not exist or is not in the from status anymore
+func (r *exchangeRateChangeRepository) Review(ctx context.Context, id int, from string, to string, reviewerID int) error {
+	return reviewChange(r.db.WithContext(ctx), id, from, to, reviewerID)
+}
+
+// Approve claims a pending change for the reviewer and applies it to exchange_rates in
+// one transaction, with the rate's history entry and audit event. When the rate guard held
+// the new rate back, quarantined is stored and linked to the change instead of applying it
+func (r *exchangeRateChangeRepository) Approve(ctx context.Context, id int, reviewerID int, quarantined *models.QuarantinedRate) (*models.ExchangeRateChange, error) {
+	var change models.ExchangeRateChange
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := reviewChange(tx, id, models.ChangePending, models.ChangeApproved, reviewerID); err != nil {
+			return err
+		}
+		if err := tx.First(&change, id).Error; err != nil {
+			return err
+		}
+
+		if quarantined != nil {
+			if err := quarantineRate(tx, quarantined); err != nil {
+				return err
+			}
+			change.QuarantineID = &quarantined.ID
+			return tx.Model(&change).Update("quarantine_id", quarantined.ID).Error
+		}
+		switch change.Action {
+		case models.ChangeActionCreate:
+			exchangeRate := &models.ExchangeRate{
+				FromCurrencyID: *change.FromCurrencyID,
+				ToCurrencyID:   *change.ToCurrencyID,
+				Rate:           *change.Rate,
+				Source:         models.RateSourceManual,
+			}
+			// the currencies may have been deleted or deactivated since the proposal
+			if err := lockActiveCurrencies(tx, exchangeRate.FromCurrencyID, exchangeRate.ToCurrencyID); err != nil {
+				return err
+			}
+			if err := createExchangeRate(ctx, tx, exchangeRate); err != nil {
+				return err
+			}
+			change.ExchangeRateID = &exchangeRate.ID
+			return tx.Model(&change).Update("exchange_rate_id", exchangeRate.ID).Error
+		case models.ChangeActionDelete:
+			return deleteExchangeRate(ctx, tx, *change.ExchangeRateID)
+		}
+		return updateExchangeRate(ctx, tx, *change.ExchangeRateID, dto.ExchangeRateUpdateRequest{
+			Rate:     change.Rate,
+			IsActive: change.IsActive,
+		})
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &change, nil
+}
+
+func reviewChange(db *gorm.DB, id int, from string, to string, reviewerID int) error {
+	tx := db.Model(&models.ExchangeRateChange{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]any{
+			"status":      to,
+			"reviewed_by": reviewerID,
+			"reviewed_at": time.Now(),
+		})
+
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return utils.ErrCodeStateChanged
+	}
+	return nil
+}
@@ -2,12 +2,15 @@ package repository
 
 import (
 	"context"
+	"currency-converter/decimal"
 	"currency-converter/dto"
 	"currency-converter/models"
 	"currency-converter/utils"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type exchangeRateRepository struct {
@@ -21,18 +24,37 @@ func NewExchangeRateRepository(db *gorm.DB) *exchangeRateRepository {
 }
 
 func (r *exchangeRateRepository) Create(ctx context.Context, exchangeRate *models.ExchangeRate) (*models.ExchangeRate, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createExchangeRate(ctx, tx, exchangeRate)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	// reload for the currency codes
+	return r.GetByID(ctx, exchangeRate.ID)
+}
+
+func (r *exchangeRateRepository) GetByID(ctx context.Context, id int) (*models.ExchangeRate, error) {
+	var exchangeRate models.ExchangeRate
 
-	err := r.db.WithContext(ctx).Create(&exchangeRate).Error
+	err := withCurrencies(r.db.WithContext(ctx).Model(&models.ExchangeRate{})).
+		Where("exchange_rates.id = ? AND exchange_rates.deleted = ?", id, false).
+		First(&exchangeRate).Error
 	if err != nil {
 		return nil, err
 	}
-	return exchangeRate, nil
+	return &exchangeRate, nil
 }
 
-func (r *exchangeRateRepository) GetByID(ctx context.Context, id int) (*models.ExchangeRate, error) {
+// GetByPair finds the non-deleted rate between two currencies by their ISO codes
+func (r *exchangeRateRepository) GetByPair(ctx context.Context, fromCode string, toCode string) (*models.ExchangeRate, error) {
 	var exchangeRate models.ExchangeRate
 
-	err := r.db.WithContext(ctx).Where("id = ? AND deleted = ?", id, false).First(&exchangeRate).Error
+	err := withCurrencies(r.db.WithContext(ctx).Model(&models.ExchangeRate{})).
+		Where("fc.code = ? AND fc.deleted = ?", fromCode, false).
+		Where("tc.code = ? AND tc.deleted = ?", toCode, false).
+		Where("exchange_rates.deleted = ?", false).
+		First(&exchangeRate).Error
 	if err != nil {
 		return nil, err
 	}
@@ -49,31 +71,62 @@ func (r *exchangeRateRepository) GetAll(ctx context.Context) ([]models.ExchangeR
 	return exchangeRates, nil
 }
 
-func (r *exchangeRateRepository) Update(ctx context.Context, id int, input dto.ExchangeRateUpdateRequest) error {
+// List returns one page of the non-deleted exchange rates matching the filter, how many
+// match in total, and the cursor of the next page
+func (r *exchangeRateRepository) List(ctx context.Context, filter dto.ExchangeRateFilter) ([]models.ExchangeRate, int64, string, error) {
+	query := r.db.WithContext(ctx).Model(&models.ExchangeRate{}).Where("exchange_rates.deleted = ?", false)
+	if filter.FromCode != "" {
+		query = query.Where("exchange_rates.from_currency_id IN (SELECT id FROM currencies WHERE code = ? AND deleted = ?)", filter.FromCode, false)
+	}
+	if filter.ToCode != "" {
+		query = query.Where("exchange_rates.to_currency_id IN (SELECT id FROM currencies WHERE code = ? AND deleted = ?)", filter.ToCode, false)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("exchange_rates.is_active = ?", *filter.IsActive)
+	}
+	if filter.UpdatedSince != nil {
+		query = query.Where("exchange_rates.updated_at >= ?", *filter.UpdatedSince)
+	}
 
-	tx := r.db.WithContext(ctx).
-		Model(&models.ExchangeRate{}).
-		Where("id = ?", id).
-		Updates(input).Update("updated_at", time.Now())
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, "", err
+	}
 
-	if tx.Error != nil {
-		return tx.Error
+	query, err := paginate(withCurrencies(query), "exchange_rates", dto.ExchangeRateSortFields, filter.Sort, filter.Cursor, filter.Limit)
+	if err != nil {
+		return nil, 0, "", err
 	}
-	if tx.RowsAffected == 0 {
-		return utils.ErrCodeNotFound
+	var exchangeRates []models.ExchangeRate
+	if err := query.Find(&exchangeRates).Error; err != nil {
+		return nil, 0, "", err
 	}
-	return nil
+
+	exchangeRates, next := nextCursor(exchangeRates, filter.Sort, filter.Limit, func(rate models.ExchangeRate, column string) (any, int) {
+		switch column {
+		case "created_at":
+			return rate.CreatedAt, rate.ID
+		case "updated_at":
+			return rate.UpdatedAt, rate.ID
+		}
+		return nil, rate.ID
+	})
+	return exchangeRates, total, next, nil
+}
+
+func (r *exchangeRateRepository) Update(ctx context.Context, id int, input dto.ExchangeRateUpdateRequest) error {
+
+	err := r.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		return updateExchangeRate(ctx, db, id, input)
+	})
+	return translateError(err)
 }
 
 func (r *exchangeRateRepository) Delete(ctx context.Context, id int) error {
 
-	return r.db.WithContext(ctx).
-		Model(&models.ExchangeRate{}).
-		Where("id = ?", id).
-		Updates(map[string]any{
-			"deleted":    true,
-			"deleted_at": time.Now(),
-		}).Error
+	return r.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		return deleteExchangeRate(ctx, db, id)
+	})
 }
 
 func (r *exchangeRateRepository) GetExchangeRateBetweenCurrencies(ctx context.Context, fromCurrencyID int, toCurrencyID int) (models.ExchangeRate, error) {
@@ -90,42 +143,305 @@ func (r *exchangeRateRepository) CreateOrUpdate(
 	ctx context.Context,
 	fromCurrencyID int,
 	toCurrencyID int,
-	rate float64,
+	rate decimal.Decimal,
+	source string,
+	quotes models.RateQuotes,
 ) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createOrUpdateExchangeRate(ctx, tx, models.AuditSync, fromCurrencyID, toCurrencyID, rate, source, quotes)
+	})
+	return translateError(err)
+}
 
+func (r *exchangeRateRepository) GetActiveExchangeRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	var exchangeRates []models.ExchangeRate
+
+	err := r.db.WithContext(ctx).Where("is_active = ? AND deleted = ?", true, false).Find(&exchangeRates).Error
+	if err != nil {
+		return nil, err
+	}
+	return exchangeRates, nil
+}
+
+// GetExchangeRateBetweenCurrenciesAt returns the rate of the pair which was in effect at the given moment,
+// not found when the pair had no rate yet or its latest entry is a tombstone
+func (r *exchangeRateRepository) GetExchangeRateBetweenCurrenciesAt(ctx context.Context, fromCurrencyID int, toCurrencyID int, at time.Time) (models.ExchangeRate, error) {
+	var history models.ExchangeRateHistory
+	err := r.db.WithContext(ctx).
+		Where("from_currency_id = ? AND to_currency_id = ? AND effective_at <= ?", fromCurrencyID, toCurrencyID, at).
+		Order("effective_at DESC, id DESC").
+		First(&history).Error
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	if history.Retired {
+		return models.ExchangeRate{}, gorm.ErrRecordNotFound
+	}
+	return history.AsExchangeRate(), nil
+}
+
+// GetExchangeRatesAt returns, for every pair, the rate which was in effect at the given moment,
+// pairs whose latest entry is a tombstone are left out
+func (r *exchangeRateRepository) GetExchangeRatesAt(ctx context.Context, at time.Time) ([]models.ExchangeRate, error) {
+	var histories []models.ExchangeRateHistory
+
+	err := r.db.WithContext(ctx).
+		Raw(`
+			SELECT * FROM (
+				SELECT DISTINCT ON (from_currency_id, to_currency_id) *
+				FROM exchange_rate_histories
+				WHERE effective_at <= ?
+				ORDER BY from_currency_id, to_currency_id, effective_at DESC, id DESC
+			) latest
+			WHERE NOT latest.retired
+		`, at).
+		Scan(&histories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeRates := make([]models.ExchangeRate, 0, len(histories))
+	for _, history := range histories {
+		exchangeRates = append(exchangeRates, history.AsExchangeRate())
+	}
+	return exchangeRates, nil
+}
+
+// the write helpers below run inside a transaction of the caller, together with the
+// history entry and audit event of the write, so that other repositories can apply a
+// rate change in the same transaction as their own rows
+
+func createExchangeRate(ctx context.Context, tx *gorm.DB, exchangeRate *models.ExchangeRate) error {
+	exchangeRate.CreatedBy = actorID(ctx)
+	exchangeRate.UpdatedBy = exchangeRate.CreatedBy
+
+	if err := tx.Create(exchangeRate).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(newExchangeRateHistory(exchangeRate)).Error; err != nil {
+		return err
+	}
+	return writeAudit(ctx, tx, models.AuditCreate, models.AuditEntityExchangeRate, exchangeRate.ID, (*models.ExchangeRate)(nil), exchangeRate)
+}
+
+func updateExchangeRate(ctx context.Context, db *gorm.DB, id int, input dto.ExchangeRateUpdateRequest) error {
+	before, err := lockExchangeRate(db, "id = ?", id)
+	if err != nil {
+		return err
+	}
+	// a rate of an inactive or deleted currency stays inactive, like a new one can't be created
+	if input.IsActive != nil && *input.IsActive && !before.IsActive {
+		if err := lockActiveCurrencies(db, before.FromCurrencyID, before.ToCurrencyID); err != nil {
+			return err
+		}
+	}
+
+	tx := db.Model(&models.ExchangeRate{}).
+		Where("id = ?", id).
+		Updates(input).Updates(map[string]any{
+		"updated_at": time.Now(),
+		"updated_by": actorID(ctx),
+	})
+
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return utils.ErrCodeNotFound
+	}
+
+	// a new rate is manual, its history entry is written below
+	if input.Rate != nil {
+		if err := db.Model(&models.ExchangeRate{}).Where("id = ?", id).Updates(map[string]any{
+			"source": models.RateSourceManual,
+			"quotes": nil,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	var after models.ExchangeRate
+	if err := db.First(&after, id).Error; err != nil {
+		return err
+	}
+	// a new rate becomes effective now, deactivating retires the pair and reactivating brings it back
+	if input.Rate != nil || before.IsActive != after.IsActive {
+		if err := db.Create(newExchangeRateHistory(&after)).Error; err != nil {
+			return err
+		}
+	}
+	return writeAudit(ctx, db, models.AuditUpdate, models.AuditEntityExchangeRate, id, before, &after)
+}
+
+func deleteExchangeRate(ctx context.Context, db *gorm.DB, id int) error {
+	before, err := lockExchangeRate(db, "id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	err = db.Model(&models.ExchangeRate{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"deleted":    true,
+			"deleted_at": time.Now(),
+			"updated_by": actorID(ctx),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	var after models.ExchangeRate
+	if err := db.First(&after, id).Error; err != nil {
+		return err
+	}
+	// a tombstone, lookups at a later moment don't find the deleted rate
+	if err := db.Create(newExchangeRateHistory(&after)).Error; err != nil {
+		return err
+	}
+	return writeAudit(ctx, db, models.AuditDelete, models.AuditEntityExchangeRate, id, before, &after)
+}
+
+// createOrUpdateExchangeRate upserts the rate of a pair and audits it under action,
+// a sync or the approval of a quarantined rate
+func createOrUpdateExchangeRate(
+	ctx context.Context,
+	tx *gorm.DB,
+	action string,
+	fromCurrencyID int,
+	toCurrencyID int,
+	rate decimal.Decimal,
+	source string,
+	quotes models.RateQuotes,
+) error {
+	// upsert the rate and append it to the history in a single statement
 	query := `
-		INSERT INTO exchange_rates (
+		WITH upserted AS (
+			INSERT INTO exchange_rates (
+				from_currency_id,
+				to_currency_id,
+				rate,
+				source,
+				quotes,
+				is_active,
+				deleted,
+				created_at,
+				updated_at,
+				created_by,
+				updated_by
+			)
+			VALUES (
+				?, ?, ?, ?, ?,
+				TRUE,
+				FALSE,
+				NOW(),
+				NOW(),
+				?, ?
+			)
+			ON CONFLICT (from_currency_id, to_currency_id)
+			WHERE deleted = FALSE
+			DO UPDATE
+			SET
+				rate       = EXCLUDED.rate,
+				source     = EXCLUDED.source,
+				quotes     = EXCLUDED.quotes,
+				is_active  = TRUE,
+				updated_at = NOW(),
+				updated_by = EXCLUDED.updated_by
+			RETURNING id, from_currency_id, to_currency_id, rate, source, quotes
+		)
+		INSERT INTO exchange_rate_histories (
+			exchange_rate_id,
 			from_currency_id,
 			to_currency_id,
 			rate,
-			is_active,
-			deleted,
-			created_at,
-			updated_at
+			source,
+			quotes,
+			effective_at,
+			created_at
 		)
-		VALUES (
-			?, ?, ?,
-			TRUE,
-			FALSE,
-			NOW(),
-			NOW()
-		)
-		ON CONFLICT (from_currency_id, to_currency_id)
-		WHERE deleted = FALSE
-		DO UPDATE
-		SET
-			rate       = EXCLUDED.rate,
-			is_active  = TRUE,
-			updated_at = NOW()
-		RETURNING *;
+		SELECT id, from_currency_id, to_currency_id, rate, source, quotes, NOW(), NOW()
+		FROM upserted
+		RETURNING exchange_rate_id;
 	`
 
-	err := r.db.WithContext(ctx).
-		Exec(query, fromCurrencyID, toCurrencyID, rate).Error
+	if err := lockActiveCurrencies(tx, fromCurrencyID, toCurrencyID); err != nil {
+		return err
+	}
 
-	if err != nil {
+	// the current row, if any, is the audit's before snapshot
+	before, err := lockExchangeRate(tx, "from_currency_id = ? AND to_currency_id = ?", fromCurrencyID, toCurrencyID)
+	if err != nil && !errors.Is(err, utils.ErrCodeNotFound) {
 		return err
 	}
 
+	var exchangeRateID int
+	actor := actorID(ctx)
+	if err := tx.Raw(query, fromCurrencyID, toCurrencyID, rate, source, quotes, actor, actor).Scan(&exchangeRateID).Error; err != nil {
+		return err
+	}
+
+	var after models.ExchangeRate
+	if err := tx.First(&after, exchangeRateID).Error; err != nil {
+		return err
+	}
+	return writeAudit(ctx, tx, action, models.AuditEntityExchangeRate, exchangeRateID, before, &after)
+}
+
+func newExchangeRateHistory(exchangeRate *models.ExchangeRate) *models.ExchangeRateHistory {
+	return &models.ExchangeRateHistory{
+		ExchangeRateID: exchangeRate.ID,
+		FromCurrencyID: exchangeRate.FromCurrencyID,
+		ToCurrencyID:   exchangeRate.ToCurrencyID,
+		Rate:           exchangeRate.Rate,
+		Source:         exchangeRate.Source,
+		Quotes:         exchangeRate.Quotes,
+		Retired:        exchangeRate.Deleted || !exchangeRate.IsActive,
+		EffectiveAt:    time.Now(),
+	}
+}
+
+// withCurrencies selects the rates together with the code, name and symbol of both
+// currencies in one query, columns of exchange_rates then need the table name
+func withCurrencies(query *gorm.DB) *gorm.DB {
+	return query.
+		Select(`exchange_rates.*,
+			fc.code AS from_currency_code, fc.name AS from_currency_name, fc.symbol AS from_currency_symbol,
+			tc.code AS to_currency_code, tc.name AS to_currency_name, tc.symbol AS to_currency_symbol`).
+		Joins("LEFT JOIN currencies fc ON fc.id = exchange_rates.from_currency_id").
+		Joins("LEFT JOIN currencies tc ON tc.id = exchange_rates.to_currency_id")
+}
+
+// lockActiveCurrencies checks that both currencies of a pair are active and not deleted,
+// ErrCodeInvalidReference otherwise. The share lock waits out a concurrent delete or
+// deactivation and holds off new ones until the transaction ends
+func lockActiveCurrencies(tx *gorm.DB, fromCurrencyID int, toCurrencyID int) error {
+	var usable []int
+	err := tx.Model(&models.Currency{}).
+		Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("id IN ? AND deleted = ? AND is_active = ?", []int{fromCurrencyID, toCurrencyID}, false, true).
+		Pluck("id", &usable).Error
+	if err != nil {
+		return err
+	}
+	if len(usable) != 2 {
+		return utils.ErrCodeInvalidReference
+	}
 	return nil
 }
+
+// lockExchangeRate loads a non-deleted exchange rate for update, the audit's before snapshot
+func lockExchangeRate(tx *gorm.DB, query string, args ...any) (*models.ExchangeRate, error) {
+	var exchangeRate models.ExchangeRate
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(query, args...).
+		Where("deleted = ?", false).
+		First(&exchangeRate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &exchangeRate, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"currency-converter/utils"
+
+	"gorm.io/gorm"
+)
+
+// paginate orders the query by the sort column with id as a tie breaker, and
+// continues after the cursor. It fetches one row more than the limit, so the
+// caller can tell whether there is a next page. Columns are qualified with table
+// so the query can join other tables
+func paginate(query *gorm.DB, table string, sortFields []string, sort string, cursor *utils.Cursor, limit int) (*gorm.DB, error) {
+	column, desc := sortColumn(sort)
+	if !slices.Contains(sortFields, column) {
+		return nil, fmt.Errorf("unknown sort column %q", column)
+	}
+
+	id := table + ".id"
+	op, dir := ">", "ASC"
+	if desc {
+		op, dir = "<", "DESC"
+	}
+
+	if cursor != nil {
+		if column == "id" {
+			query = query.Where(id+" "+op+" ?", cursor.ID)
+		} else {
+			value, err := cursorValue(column, cursor.Value)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("("+table+"."+column+", "+id+") "+op+" (?, ?)", value, cursor.ID)
+		}
+	}
+
+	if column != "id" {
+		query = query.Order(table + "." + column + " " + dir)
+	}
+	return query.Order(id + " " + dir).Limit(limit + 1), nil
+}
+
+// nextCursor returns the cursor after the last row, empty when rows is the final page.
+// rows holds up to limit+1 rows as fetched by paginate, value gives the sort column of a row
+func nextCursor[T any](rows []T, sort string, limit int, value func(T, string) (any, int)) ([]T, string) {
+	if len(rows) <= limit {
+		return rows, ""
+	}
+	rows = rows[:limit]
+
+	column, _ := sortColumn(sort)
+	last, id := value(rows[len(rows)-1], column)
+
+	cursor := utils.Cursor{Sort: sort, ID: id}
+	switch v := last.(type) {
+	case time.Time:
+		cursor.Value = v.UTC().Format(time.RFC3339Nano)
+	case string:
+		cursor.Value = v
+	}
+	return rows, utils.EncodeCursor(cursor)
+}
+
+func sortColumn(sort string) (string, bool) {
+	return strings.CutPrefix(sort, "-")
+}
+
+func cursorValue(column, value string) (any, error) {
+	if column == "created_at" || column == "updated_at" {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("malformed cursor value %q", value)
+		}
+		return t, nil
+	}
+	return value, nil
+}
+
+// escapeLike makes user input match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
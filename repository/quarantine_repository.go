@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type quarantineRepository struct {
+	db *gorm.DB
+}
+
+func NewQuarantineRepository(db *gorm.DB) *quarantineRepository {
+	return &quarantineRepository{
+		db: db,
+	}
+}
+
+// Create quarantines a rate, superseding the pending one of the same pair so that
+// repeated syncs keep a single rate per pair in the review queue
+func (r *quarantineRepository) Create(ctx context.Context, quarantined *models.QuarantinedRate) (*models.QuarantinedRate, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return quarantineRate(tx, quarantined)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return quarantined, nil
+}
+
+func (r *quarantineRepository) GetByID(ctx context.Context, id int) (*models.QuarantinedRate, error) {
+	var quarantined models.QuarantinedRate
+
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&quarantined).Error
+	if err != nil {
+		return nil, err
+	}
+	return &quarantined, nil
+}
+
+// GetAll returns the quarantined rates with the given status, all of them if status is empty
+func (r *quarantineRepository) GetAll(ctx context.Context, status string) ([]models.QuarantinedRate, error) {
+	var quarantined []models.QuarantinedRate
+
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&quarantined).Error; err != nil {
+		return nil, err
+	}
+	return quarantined, nil
+}
+
+// Approve claims a pending quarantined rate for the reviewer and puts the rate live in one
+// transaction, the same way it would have gone live without the guard, together with the
+// audit events of both. ErrCodeStateChanged means it is not pending anymore
+func (r *quarantineRepository) Approve(ctx context.Context, id int, reviewerID int) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		quarantined, err := reviewQuarantined(ctx, tx, id, models.QuarantineApproved, models.AuditApprove, reviewerID)
+		if err != nil {
+			return err
+		}
+
+		// a rate written after it was quarantined, by a sync or a manual change, is newer
+		current, err := lockExchangeRate(tx, "from_currency_id = ? AND to_currency_id = ?", quarantined.FromCurrencyID, quarantined.ToCurrencyID)
+		if err != nil && !errors.Is(err, utils.ErrCodeNotFound) {
+			return err
+		}
+		if current != nil && current.UpdatedAt.After(quarantined.CreatedAt) {
+			return utils.ErrCodeOutdated
+		}
+
+		if quarantined.Source == models.RateSourceManual && quarantined.ExchangeRateID != nil {
+			return updateExchangeRate(ctx, tx, *quarantined.ExchangeRateID, dto.ExchangeRateUpdateRequest{Rate: &quarantined.Rate})
+		}
+		return createOrUpdateExchangeRate(ctx, tx, models.AuditApprove, quarantined.FromCurrencyID, quarantined.ToCurrencyID, quarantined.Rate, quarantined.Source, quarantined.Quotes)
+	})
+	return translateError(err)
+}
+
+// Reject closes a pending quarantined rate for the reviewer, ErrCodeStateChanged means it is not pending anymore
+func (r *quarantineRepository) Reject(ctx context.Context, id int, reviewerID int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := reviewQuarantined(ctx, tx, id, models.QuarantineRejected, models.AuditReject, reviewerID)
+		return err
+	})
+}
+
+// reviewQuarantined moves a pending quarantined rate to status and writes its audit event,
+// it returns the reviewed row
+func reviewQuarantined(ctx context.Context, tx *gorm.DB, id int, status string, action string, reviewerID int) (*models.QuarantinedRate, error) {
+	var before models.QuarantinedRate
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if before.Status != models.QuarantinePending {
+		return nil, utils.ErrCodeStateChanged
+	}
+
+	after := before
+	now := time.Now()
+	after.Status = status
+	after.ReviewedBy = &reviewerID
+	after.ReviewedAt = &now
+	err = tx.Model(&after).Updates(map[string]any{
+		"status":      after.Status,
+		"reviewed_by": reviewerID,
+		"reviewed_at": now,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeAudit(ctx, tx, action, models.AuditEntityQuarantinedRate, id, &before, &after); err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+// quarantineRate stores a quarantined rate in the caller's transaction, the pending rate of
+// the same pair is superseded first. The partial unique index on pending pairs turns a
+// concurrent quarantine of the same pair into ErrCodeConflict
+func quarantineRate(tx *gorm.DB, quarantined *models.QuarantinedRate) error {
+	err := tx.Model(&models.QuarantinedRate{}).
+		Where("from_currency_id = ? AND to_currency_id = ? AND status = ?", quarantined.FromCurrencyID, quarantined.ToCurrencyID, models.QuarantinePending).
+		Update("status", models.QuarantineSuperseded).Error
+	if err != nil {
+		return err
+	}
+	return tx.Create(quarantined).Error
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *refreshTokenRepository {
+	return &refreshTokenRepository{
+		db: db,
+	}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate marks the token as used and stores its successor in one transaction,
+// ErrCodeNotFound means the token was already rotated or revoked
+func (r *refreshTokenRepository) Rotate(ctx context.Context, id int, next *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+			Update("rotated_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return utils.ErrCodeNotFound
+		}
+
+		return tx.Create(next).Error
+	})
+}
+
+// RevokeFamily revokes every refresh token of the family and puts the family on
+// the access token revocation list until the given time
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, until time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		return revoke(tx, familyID, until)
+	})
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"currency-converter/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRevokedTokenRepository(db *gorm.DB) *revokedTokenRepository {
+	return &revokedTokenRepository{
+		db: db,
+	}
+}
+
+func (r *revokedTokenRepository) Revoke(ctx context.Context, tokenID string, until time.Time) error {
+	return revoke(r.db.WithContext(ctx), tokenID, until)
+}
+
+// IsRevoked reports whether any of the given token or session ids is on the revocation list
+func (r *revokedTokenRepository) IsRevoked(ctx context.Context, tokenIDs ...string) (bool, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&models.RevokedToken{}).
+		Where("token_id IN ? AND expires_at > ?", tokenIDs, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// revoke adds an id to the revocation list, keeping the later expiry if it is already there
+func revoke(tx *gorm.DB, tokenID string, until time.Time) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "token_id"}},
+		DoUpdates: clause.Set{{
+			Column: clause.Column{Name: "expires_at"},
+			Value:  gorm.Expr("GREATEST(revoked_tokens.expires_at, EXCLUDED.expires_at)"),
+		}},
+	}).Create(&models.RevokedToken{TokenID: tokenID, ExpiresAt: until}).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"currency-converter/models"
+	"database/sql/driver"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type syncRunRepository struct {
+	db *gorm.DB
+}
+
+func NewSyncRunRepository(db *gorm.DB) *syncRunRepository {
+	return &syncRunRepository{
+		db: db,
+	}
+}
+
+// Save replaces the last run of the base currency
+func (r *syncRunRepository) Save(ctx context.Context, run *models.SyncRun) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(run).Error
+}
+
+func (r *syncRunRepository) GetAll(ctx context.Context) ([]models.SyncRun, error) {
+	var runs []models.SyncRun
+	err := r.db.WithContext(ctx).Order("base_code").Find(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// WithAdvisoryLock runs fn only if the postgres advisory lock could be taken. The lock is
+// held by the session of a dedicated connection rather than by a transaction, so that no
+// transaction stays open while fn calls the providers. It is released when fn returns,
+// or by postgres when the connection is lost
+func (r *syncRunRepository) WithAdvisoryLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	acquired := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		return false, err
+	}
+	if !acquired {
+		return false, nil
+	}
+
+	defer func() {
+		// unlock even if ctx is cancelled, a connection which may still hold the lock
+		// must not go back to the pool
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", key)
+		if err != nil {
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+	return true, fn(ctx)
+}
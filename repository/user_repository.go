@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"currency-converter/models"
+	"currency-converter/utils"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -39,3 +41,34 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 
 	return &user, nil
 }
+
+func (r *userRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	var user models.User
+
+	err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	tx := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"role":       role,
+			"updated_at": time.Now(),
+		})
+
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return utils.ErrCodeNotFound
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"currency-converter/controller"
 	"currency-converter/middleware"
+	"currency-converter/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,29 +14,68 @@ func SetupRouter(
 	currencyController *controller.CurrencyController,
 	exchangeRateController *controller.ExchangeRateController,
 	conversionController *controller.ConversionController,
+	syncRunController *controller.SyncRunController,
+	quarantineController *controller.QuarantineController,
+	exchangeRateChangeController *controller.ExchangeRateChangeController,
+	apiKeyController *controller.APIKeyController,
+	jwksController *controller.JWKSController,
+	auditController *controller.AuditController,
 ) *gin.Engine {
 
 	r := gin.Default()
+	r.Use(middleware.RequestID())
 
 	r.POST("/register", userController.Register)
 	r.POST("/login", userController.Login)
+	r.POST("/token/refresh", userController.Refresh)
+	r.GET("/.well-known/jwks.json", jwksController.GetJWKS)
 
 	r.Use(authMiddleware.Handle())
 
-	r.POST("/currencies", currencyController.CreateCurrency)
+	// admins and rate managers can change currencies and rates, everyone else can only read and convert
+	canManageRates := middleware.RequireRoles(models.RoleAdmin, models.RoleRateManager)
+	adminOnly := middleware.RequireRoles(models.RoleAdmin)
+	// account and key management needs a login, an API key can't be used for it
+	userToken := middleware.RequireUserToken()
+
+	r.POST("/logout", userToken, userController.Logout)
+
+	r.POST("/api-keys", userToken, apiKeyController.CreateAPIKey)
+	r.GET("/api-keys", userToken, apiKeyController.GetAPIKeys)
+	r.DELETE("/api-keys/:id", userToken, apiKeyController.RevokeAPIKey)
+
+	r.PATCH("/users/:id/role", userToken, adminOnly, userController.UpdateRole)
+
+	r.POST("/currencies", canManageRates, currencyController.CreateCurrency)
 	r.GET("/currencies", currencyController.GetCurrencies)
 	r.GET("/currencies/:id", currencyController.GetCurrencyByID)
-	r.PATCH("/currencies/:id", currencyController.UpdateCurrency)
-	r.DELETE("/currencies/:id", currencyController.DeleteCurrency)
+	r.PATCH("/currencies/:id", canManageRates, currencyController.UpdateCurrency)
+	r.DELETE("/currencies/:id", canManageRates, currencyController.DeleteCurrency)
+	r.POST("/currencies/:id/restore", canManageRates, currencyController.RestoreCurrency)
 
-	r.POST("/exchange-rates", exchangeRateController.CreateExchangeRate)
+	r.POST("/exchange-rates", canManageRates, exchangeRateChangeController.ProposeCreate) // created once another user approves it
 	r.GET("/exchange-rates", exchangeRateController.GetAllExchangeRates)
 	r.GET("/exchange-rates/:id", exchangeRateController.GetExchangeRateByID)
-	r.PATCH("/exchange-rates/:id", exchangeRateController.UpdateExchangeRate) 
-	r.DELETE("/exchange-rates/:id", exchangeRateController.DeleteExchangeRate)
-	r.POST("/exchange-rates/sync/:code", exchangeRateController.SyncExchangeRates) // /exchange-rates/sync/USD
+	r.GET("/exchange-rates/pair/:from/:to", exchangeRateController.GetExchangeRateByPair) // /exchange-rates/pair/USD/INR
+	r.PATCH("/exchange-rates/pair/:from/:to", canManageRates, exchangeRateChangeController.ProposeChangeByPair) // same as PATCH /exchange-rates/:id
+	r.PATCH("/exchange-rates/:id", canManageRates, exchangeRateChangeController.ProposeChange) // applied once another user approves it
+	r.DELETE("/exchange-rates/:id", canManageRates, exchangeRateChangeController.ProposeDelete) // deleted once another user approves it
+	r.POST("/exchange-rates/sync/:code", canManageRates, syncRunController.SyncExchangeRates) // /exchange-rates/sync/USD
+	r.GET("/exchange-rates/sync/status", syncRunController.GetSyncRuns)
+
+	r.GET("/exchange-rates/quarantine", quarantineController.GetQuarantinedRates) // ?status=pending|approved|rejected|superseded|all
+	r.POST("/exchange-rates/quarantine/:id/approve", adminOnly, quarantineController.ApproveQuarantinedRate)
+	r.POST("/exchange-rates/quarantine/:id/reject", adminOnly, quarantineController.RejectQuarantinedRate)
+
+	r.GET("/exchange-rate-changes", exchangeRateChangeController.GetChanges) // ?status=pending|approved|rejected|all
+	r.POST("/exchange-rate-changes/:id/approve", canManageRates, exchangeRateChangeController.ApproveChange)
+	r.POST("/exchange-rate-changes/:id/reject", canManageRates, exchangeRateChangeController.RejectChange)
+
+	r.GET("/audit", adminOnly, auditController.GetAuditEvents) // ?entity=exchange_rate&entity_id=1&actor_id=2&action=update&from=2024-01-01&to=2024-01-31&page=1&page_size=50
 
-	r.GET("/convert", conversionController.ConvertCurrency) // ?from=USD&to=INR&amount=100
+	r.GET("/convert", conversionController.ConvertCurrency) // ?from=USD&to=INR&amount=100&at=2024-01-31
+	r.POST("/convert/batch", conversionController.ConvertBatch)
+	r.GET("/convert/multi", conversionController.ConvertToMany) // ?from=USD&amount=100&to=INR,EUR or to=*
 
 	return r
 }
@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Syncer syncs the bases under the sync advisory lock and records each run,
+// it reports false when another replica holds the lock
+type Syncer interface {
+	SyncBases(ctx context.Context, bases []string) (bool, error)
+}
+
+// Scheduler syncs the configured base currencies in the background,
+// only the replica holding the advisory lock syncs on each tick
+type Scheduler struct {
+	schedule Schedule
+	bases    []string
+	jitter   time.Duration
+	syncer   Syncer
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(spec string, bases []string, jitter time.Duration, syncer Syncer) (*Scheduler, error) {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync schedule: %w", err)
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("sync schedule %q never fires", spec)
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("no base currencies to sync")
+	}
+	return &Scheduler{
+		schedule: schedule,
+		bases:    bases,
+		jitter:   jitter,
+		syncer:   syncer,
+	}, nil
+}
+
+// Start runs the scheduler until Stop is called or ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.loop(ctx)
+	}()
+}
+
+// Stop cancels a run in progress and waits for the scheduler to exit
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	for {
+		next := s.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("sync scheduler: schedule never fires again, stopping")
+			return
+		}
+		// spread replicas and providers calls a little
+		if s.jitter > 0 {
+			next = next.Add(rand.N(s.jitter))
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(ctx)
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context) {
+	acquired, err := s.syncer.SyncBases(ctx, s.bases)
+	if err != nil {
+		log.Printf("sync scheduler: run failed: %v", err)
+		return
+	}
+	if !acquired {
+		log.Printf("sync scheduler: another replica is syncing, skipping this run")
+	}
+}
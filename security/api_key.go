@@ -0,0 +1,37 @@
+package security
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// apiKeyPrefixTag makes leaked keys easy to recognise in logs and by secret scanners
+const apiKeyPrefixTag = "cck_"
+
+// GenerateAPIKey returns a new key as "<prefix>.<secret>", the prefix is stored
+// in clear to find the key and the secret is hashed with HashPassword
+func GenerateAPIKey() (key string, prefix string, secret string, err error) {
+	p := make([]byte, 6)
+	if _, err := rand.Read(p); err != nil {
+		return "", "", "", err
+	}
+	s := make([]byte, 32)
+	if _, err := rand.Read(s); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = apiKeyPrefixTag + hex.EncodeToString(p)
+	secret = base64.RawURLEncoding.EncodeToString(s)
+	return prefix + "." + secret, prefix, secret, nil
+}
+
+// SplitAPIKey splits a key into its prefix and secret
+func SplitAPIKey(key string) (prefix string, secret string, ok bool) {
+	prefix, secret, ok = strings.Cut(key, ".")
+	if !ok || !strings.HasPrefix(prefix, apiKeyPrefixTag) || secret == "" {
+		return "", "", false
+	}
+	return prefix, secret, true
+}
@@ -0,0 +1,28 @@
+package security
+
+import "context"
+
+type claimsCtxKeyType struct{}
+
+var claimsCtxKey = claimsCtxKeyType{}
+
+// WithClaims returns a copy of ctx carrying the authenticated caller
+func WithClaims(ctx context.Context, claims *RequestClaims) context.Context {
+	return context.WithValue(ctx, claimsCtxKey, claims)
+}
+
+// ClaimsFromContext returns the authenticated caller, if any
+func ClaimsFromContext(ctx context.Context) (*RequestClaims, bool) {
+	claims, ok := ctx.Value(claimsCtxKey).(*RequestClaims)
+	return claims, ok && claims != nil
+}
+
+// UserIDFromContext returns the id of the authenticated user, false for
+// background work like the scheduled sync which runs without a caller
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || claims.UserID == 0 {
+		return 0, false
+	}
+	return claims.UserID, true
+}
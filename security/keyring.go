@@ -0,0 +1,210 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// key files in the keys directory, the file name without extension is the kid
+const (
+	privateKeySuffix = ".pem"     // PKCS#8 Ed25519 private key, can sign and verify
+	publicKeySuffix  = ".pub.pem" // PKIX Ed25519 public key of a retired key, only verifies
+)
+
+type signingKey struct {
+	private ed25519.PrivateKey // nil for retired keys
+	public  ed25519.PublicKey
+}
+
+// KeyRing holds the Ed25519 keys tokens are signed and verified with.
+//
+// Rotation: add a new key and make it the active one, then turn the previous
+// private key into a .pub.pem file (or leave it) and only delete it once every
+// token it signed has expired
+type KeyRing struct {
+	keys   map[string]signingKey
+	active string
+}
+
+// LoadKeyRing loads every key of dir, the active signing key is activeKid or,
+// when empty, the private key with the greatest kid (date based kids sort naturally)
+func LoadKeyRing(dir string, activeKid string) (*KeyRing, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading keys directory: %w", err)
+	}
+
+	ring := &KeyRing{keys: map[string]signingKey{}}
+	var signingKids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+
+		switch {
+		case strings.HasSuffix(name, publicKeySuffix):
+			kid := strings.TrimSuffix(name, publicKeySuffix)
+			public, err := readPublicKey(filepath.Join(dir, name))
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", kid, err)
+			}
+			if _, ok := ring.keys[kid]; !ok {
+				ring.keys[kid] = signingKey{public: public}
+			}
+		case strings.HasSuffix(name, privateKeySuffix):
+			kid := strings.TrimSuffix(name, privateKeySuffix)
+			private, err := readPrivateKey(filepath.Join(dir, name))
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", kid, err)
+			}
+			ring.keys[kid] = signingKey{private: private, public: private.Public().(ed25519.PublicKey)}
+			signingKids = append(signingKids, kid)
+		}
+	}
+
+	if activeKid == "" {
+		if len(signingKids) == 0 {
+			return nil, fmt.Errorf("no private key in %s", dir)
+		}
+		sort.Strings(signingKids)
+		activeKid = signingKids[len(signingKids)-1]
+	}
+	if key, ok := ring.keys[activeKid]; !ok || key.private == nil {
+		return nil, fmt.Errorf("no private key for signing key id %q", activeKid)
+	}
+	ring.active = activeKid
+
+	return ring, nil
+}
+
+// GenerateSigningKey writes a new private key for kid into dir
+func GenerateSigningKey(dir string, kid string) (string, error) {
+	if kid == "" || strings.ContainsAny(kid, `/\.`) {
+		return "", fmt.Errorf("invalid key id %q", kid)
+	}
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, kid+privateKeySuffix)
+	// O_EXCL so an existing key is never overwritten
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (k *KeyRing) signing() (string, ed25519.PrivateKey) {
+	return k.active, k.keys[k.active].private
+}
+
+func (k *KeyRing) verifying(kid string) (ed25519.PublicKey, bool) {
+	key, ok := k.keys[kid]
+	return key.public, ok
+}
+
+// JWK is an Ed25519 public key as published in the JWKS (RFC 8037)
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every public key of the ring, retired ones included, so that
+// tokens signed before a rotation can still be verified downstream
+func (k *KeyRing) JWKS() JWKSet {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKSet{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		set.Keys = append(set.Keys, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k.keys[kid].public),
+			Kid: kid,
+			Alg: "EdDSA",
+			Use: "sig",
+		})
+	}
+	return set
+}
+
+func readPrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an Ed25519 private key")
+	}
+	return private, nil
+}
+
+func readPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("not an Ed25519 public key")
+	}
+	return public, nil
+}
+
+func readPEM(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	return block.Bytes, nil
+}
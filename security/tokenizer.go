@@ -1,34 +1,66 @@
 package security
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"currency-converter/config"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// RevocationStore holds the revoked access token and session ids
+type RevocationStore interface {
+	IsRevoked(ctx context.Context, tokenIDs ...string) (bool, error)
+}
+
 type TokenService struct {
-	secret    string
-	expiryMin int
+	keys          *KeyRing
+	expiryMin     int
+	refreshExpiry time.Duration
+	revocations   RevocationStore
 }
 
-func NewTokenService(authCfg *config.AuthConfig) *TokenService {
+func NewTokenService(authCfg *config.AuthConfig, keys *KeyRing, revocations RevocationStore) *TokenService {
 	return &TokenService{
-		secret:    authCfg.Secret,
-		expiryMin: authCfg.ExpiryMin,
+		keys:          keys,
+		expiryMin:     authCfg.ExpiryMin,
+		refreshExpiry: authCfg.RefreshExpiry,
+		revocations:   revocations,
 	}
 }
 
+// JWKS returns the public keys downstream services verify tokens with
+func (ts *TokenService) JWKS() JWKSet {
+	return ts.keys.JWKS()
+}
+
+// AccessTokenTTL is how long an access token stays valid after it is issued
+func (ts *TokenService) AccessTokenTTL() time.Duration {
+	return time.Duration(ts.expiryMin) * time.Minute
+}
+
 func (ts *TokenService) GenerateAccessToken(payload RequestClaims) (string, error) {
 	now := time.Now()
-	expiryTime := now.Add(time.Duration(ts.expiryMin) * time.Minute)
+	expiryTime := now.Add(ts.AccessTokenTTL())
 
+	jti, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	payload.ID = jti
 	payload.ExpiresAt = jwt.NewNumericDate(expiryTime)
 	payload.IssuedAt = jwt.NewNumericDate(now)
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	signedToken, err := token.SignedString([]byte(ts.secret))
+	kid, privateKey := ts.keys.signing()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, payload)
+	token.Header["kid"] = kid
+	signedToken, err := token.SignedString(privateKey)
 	if err != nil {
 		return "", err
 	}
@@ -36,7 +68,7 @@ func (ts *TokenService) GenerateAccessToken(payload RequestClaims) (string, erro
 	return signedToken, nil
 }
 
-func (ts *TokenService) ValidateAccessToken(accessToken string) (*RequestClaims, error) {
+func (ts *TokenService) ValidateAccessToken(ctx context.Context, accessToken string) (*RequestClaims, error) {
 
 	claims := &RequestClaims{}
 
@@ -44,17 +76,62 @@ func (ts *TokenService) ValidateAccessToken(accessToken string) (*RequestClaims,
 		accessToken,
 		claims,
 		func(t *jwt.Token) (any, error) {
-			if t.Method != jwt.SigningMethodHS256 {
-				return nil, jwt.ErrSignatureInvalid
+			// any key of the ring verifies, so tokens survive a rotation
+			kid, _ := t.Header["kid"].(string)
+			publicKey, ok := ts.keys.verifying(kid)
+			if !ok {
+				return nil, jwt.ErrTokenUnverifiable
 			}
-			return []byte(ts.secret), nil
+			return publicKey, nil
 		},
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
 	)
 
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid token")
 	}
 
+	// a token is revoked on its own (jti) or with its whole session (sid)
+	if ts.revocations != nil {
+		ids := []string{claims.ID}
+		if claims.SessionID != "" {
+			ids = append(ids, claims.SessionID)
+		}
+		revoked, err := ts.revocations.IsRevoked(ctx, ids...)
+		if err != nil {
+			return nil, errors.New("unable to check token revocation")
+		}
+		if revoked {
+			return nil, errors.New("token has been revoked")
+		}
+	}
+
 	return claims, nil
 }
+
+// GenerateRefreshToken returns an opaque refresh token, the hash to store and its expiry
+func (ts *TokenService) GenerateRefreshToken() (token string, tokenHash string, expiresAt time.Time, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), time.Now().Add(ts.refreshExpiry), nil
+}
+
+// HashRefreshToken hashes a refresh token for storage and lookup, the tokens are
+// random enough that a plain sha256 is safe and keeps the lookup deterministic
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewTokenID returns a random id for a token (jti) or a session
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
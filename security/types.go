@@ -3,6 +3,12 @@ package security
 import "github.com/golang-jwt/jwt/v5"
 
 type RequestClaims struct {
-	UserID   int `json:"sub"`
+	UserID    int    `json:"sub"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // refresh token family the token was issued for
 	jwt.RegisteredClaims
-}
\ No newline at end of file
+
+	// set when the caller authenticated with an API key instead of a JWT
+	APIKeyID int      `json:"-"`
+	Scopes   []string `json:"-"`
+}
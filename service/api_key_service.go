@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"currency-converter/models"
+	"currency-converter/security"
+	"currency-converter/utils"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// lastUsedInterval is how stale an API key's last_used_at may get
+const lastUsedInterval = time.Minute
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	GetAllByUser(ctx context.Context, userID int) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id int, userID *int) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time, interval time.Duration) error
+}
+
+type apiKeyService struct {
+	repo     APIKeyRepository
+	userRepo UserRepository
+}
+
+func NewAPIKeyService(repo APIKeyRepository, userRepo UserRepository) *apiKeyService {
+	return &apiKeyService{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+// CreateAPIKey returns the stored key and the full key, which is only ever shown once
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID int, name string, scopes []string, expiresInDays *int) (*models.APIKey, string, *utils.AppError) {
+	for _, scope := range scopes {
+		if !models.IsValidScope(scope) {
+			return nil, "", utils.New(http.StatusBadRequest, "Invalid scope "+scope+", expected convert, read or write")
+		}
+	}
+
+	var expiresAt *time.Time
+	if expiresInDays != nil {
+		if *expiresInDays <= 0 {
+			return nil, "", utils.New(http.StatusBadRequest, "expires_in_days must be greater than zero")
+		}
+		t := time.Now().AddDate(0, 0, *expiresInDays)
+		expiresAt = &t
+	}
+
+	fullKey, prefix, secret, err := security.GenerateAPIKey()
+	if err != nil {
+		return nil, "", utils.New(http.StatusInternalServerError, "Failed to generate API key")
+	}
+	hash, err := security.HashPassword(secret)
+	if err != nil {
+		return nil, "", utils.New(http.StatusInternalServerError, "Failed to hash API key")
+	}
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    models.Scopes(scopes),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", utils.New(http.StatusInternalServerError, "Failed to create API key")
+	}
+
+	return key, fullKey, nil
+}
+
+func (s *apiKeyService) GetAPIKeys(ctx context.Context, userID int) ([]models.APIKey, *utils.AppError) {
+	keys, err := s.repo.GetAllByUser(ctx, userID)
+	if err != nil {
+		return nil, utils.New(http.StatusInternalServerError, "Failed to fetch API keys")
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes one of the caller's keys, admins can revoke anyone's
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id int, userID int, role string) *utils.AppError {
+	owner := &userID
+	if role == models.RoleAdmin {
+		owner = nil
+	}
+
+	if err := s.repo.Revoke(ctx, id, owner); err != nil {
+		if errors.Is(err, utils.ErrCodeNotFound) {
+			return utils.New(http.StatusNotFound, "API key not found")
+		}
+		return utils.New(http.StatusInternalServerError, "Failed to revoke API key")
+	}
+	return nil
+}
+
+// AuthenticateAPIKey turns a valid key into the claims of its owner, restricted to the key's scopes
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, fullKey string) (*security.RequestClaims, error) {
+	prefix, secret, ok := security.SplitAPIKey(fullKey)
+	if !ok {
+		return nil, errors.New("invalid API key")
+	}
+
+	key, err := s.repo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, errors.New("invalid API key")
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, errors.New("API key has been revoked")
+	}
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, errors.New("API key has expired")
+	}
+
+	ok, err = security.ComparePassword(secret, key.KeyHash)
+	if err != nil || !ok {
+		return nil, errors.New("invalid API key")
+	}
+
+	// the key acts with the owner's current role
+	user, err := s.userRepo.GetUserByID(ctx, key.UserID)
+	if err != nil {
+		return nil, errors.New("invalid API key")
+	}
+
+	if err := s.repo.TouchLastUsed(ctx, key.ID, now, lastUsedInterval); err != nil {
+		log.Printf("error in recording use of API key %d: %v", key.ID, err)
+	}
+
+	return &security.RequestClaims{
+		UserID:   user.ID,
+		Role:     user.Role,
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"net/http"
+)
+
+type AuditRepository interface {
+	GetAll(ctx context.Context, filter dto.AuditFilter) ([]models.AuditEvent, int64, error)
+}
+
+type auditService struct {
+	repo AuditRepository
+}
+
+func NewAuditService(repo AuditRepository) *auditService {
+	return &auditService{
+		repo: repo,
+	}
+}
+
+func (s *auditService) GetAuditEvents(ctx context.Context, filter dto.AuditFilter) ([]models.AuditEvent, int64, *utils.AppError) {
+	events, total, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, 0, utils.New(http.StatusInternalServerError, "error in fetching audit events")
+	}
+	return events, total, nil
+}
@@ -6,23 +6,47 @@ import (
 	"currency-converter/models"
 	"currency-converter/security"
 	"currency-converter/utils"
+	"errors"
 	"net/http"
+	"time"
 )
 
 type UserRepository interface {
 	GetUserByEmail(context.Context, string) (*models.User, error)
 	CreateUser(context.Context, *models.User) (int, error)
+	GetUserByID(context.Context, int) (*models.User, error)
+	UpdateRole(context.Context, int, string) error
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	Rotate(ctx context.Context, id int, next *models.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string, until time.Time) error
+}
+
+type RevokedTokenRepository interface {
+	Revoke(ctx context.Context, tokenID string, until time.Time) error
 }
 
 type userService struct {
-	userRepo     UserRepository
-	tokenService *security.TokenService
+	userRepo         UserRepository
+	refreshTokenRepo RefreshTokenRepository
+	revokedTokenRepo RevokedTokenRepository
+	tokenService     *security.TokenService
 }
 
-func NewUserService(userRepo UserRepository, tokenService *security.TokenService) *userService {
+func NewUserService(
+	userRepo UserRepository,
+	refreshTokenRepo RefreshTokenRepository,
+	revokedTokenRepo RevokedTokenRepository,
+	tokenService *security.TokenService,
+) *userService {
 	return &userService{
-		userRepo:     userRepo,
-		tokenService: tokenService,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revokedTokenRepo: revokedTokenRepo,
+		tokenService:     tokenService,
 	}
 }
 
@@ -36,6 +60,7 @@ func (s *userService) Register(ctx context.Context, req dto.RegisterRequest) (in
 	newUser := models.User{
 		Email:        req.Email,
 		PasswordHash: hashedPassword,
+		Role:         models.RoleViewer,
 	}
 
 	userID, err := s.userRepo.CreateUser(ctx, &newUser)
@@ -59,8 +84,102 @@ func (s *userService) Login(ctx context.Context, req dto.LoginRequest) (dto.Logi
 		return dto.LoginResult{}, utils.New(http.StatusUnauthorized, "Invalid credentials")
 	}
 
+	// every login starts a new refresh token family
+	familyID, err := security.NewTokenID()
+	if err != nil {
+		return dto.LoginResult{}, utils.New(http.StatusInternalServerError, "Internal server error")
+	}
+
+	refreshToken, refreshHash, refreshExpiresAt, err := s.tokenService.GenerateRefreshToken()
+	if err != nil {
+		return dto.LoginResult{}, utils.New(http.StatusInternalServerError, "Internal server error")
+	}
+
+	err = s.refreshTokenRepo.Create(ctx, &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		ExpiresAt: refreshExpiresAt,
+	})
+	if err != nil {
+		return dto.LoginResult{}, utils.New(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return s.issueTokens(user, familyID, refreshToken)
+}
+
+// Refresh rotates a refresh token, presenting a token which was already rotated means
+// it leaked, so the whole family is revoked together with its access tokens
+func (s *userService) Refresh(ctx context.Context, refreshToken string) (dto.LoginResult, *utils.AppError) {
+	current, err := s.refreshTokenRepo.GetByHash(ctx, security.HashRefreshToken(refreshToken))
+	if err != nil {
+		return dto.LoginResult{}, utils.New(http.StatusUnauthorized, "Invalid refresh token")
+	}
+
+	if current.RevokedAt != nil {
+		return dto.LoginResult{}, utils.New(http.StatusUnauthorized, "Refresh token has been revoked")
+	}
+	if current.RotatedAt != nil {
+		return dto.LoginResult{}, s.revokeReusedFamily(ctx, current.FamilyID)
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return dto.LoginResult{}, utils.New(http.StatusUnauthorized, "Refresh token has expired")
+	}
+
+	// pick up role changes made since the last token was issued
+	user, err := s.userRepo.GetUserByID(ctx, current.UserID)
+	if err != nil {
+		return dto.LoginResult{}, utils.New(http.StatusUnauthorized, "Invalid refresh token")
+	}
+
+	nextToken, nextHash, nextExpiresAt, err := s.tokenService.GenerateRefreshToken()
+	if err != nil {
+		return dto.LoginResult{}, utils.New(http.StatusInternalServerError, "Internal server error")
+	}
+
+	err = s.refreshTokenRepo.Rotate(ctx, current.ID, &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  current.FamilyID,
+		TokenHash: nextHash,
+		ExpiresAt: nextExpiresAt,
+	})
+	if err != nil {
+		// a concurrent refresh won the race with the same token
+		if errors.Is(err, utils.ErrCodeNotFound) {
+			return dto.LoginResult{}, s.revokeReusedFamily(ctx, current.FamilyID)
+		}
+		return dto.LoginResult{}, utils.New(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return s.issueTokens(user, current.FamilyID, nextToken)
+}
+
+// Logout revokes the caller's session, both its refresh tokens and its access tokens
+func (s *userService) Logout(ctx context.Context, claims *security.RequestClaims) *utils.AppError {
+	var err error
+	if claims.SessionID != "" {
+		err = s.refreshTokenRepo.RevokeFamily(ctx, claims.SessionID, time.Now().Add(s.tokenService.AccessTokenTTL()))
+	} else {
+		err = s.revokedTokenRepo.Revoke(ctx, claims.ID, claims.ExpiresAt.Time)
+	}
+	if err != nil {
+		return utils.New(http.StatusInternalServerError, "Failed to revoke session")
+	}
+	return nil
+}
+
+func (s *userService) revokeReusedFamily(ctx context.Context, familyID string) *utils.AppError {
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, familyID, time.Now().Add(s.tokenService.AccessTokenTTL())); err != nil {
+		return utils.New(http.StatusInternalServerError, "Internal server error")
+	}
+	return utils.New(http.StatusUnauthorized, "Refresh token reuse detected, the session has been revoked")
+}
+
+func (s *userService) issueTokens(user *models.User, familyID string, refreshToken string) (dto.LoginResult, *utils.AppError) {
 	payload := security.RequestClaims{
-		UserID: user.ID,
+		UserID:    user.ID,
+		Role:      user.Role,
+		SessionID: familyID,
 	}
 
 	token, err := s.tokenService.GenerateAccessToken(payload)
@@ -69,7 +188,48 @@ func (s *userService) Login(ctx context.Context, req dto.LoginRequest) (dto.Logi
 	}
 
 	return dto.LoginResult{
-		ID:    user.ID,
-		Token: token,
+		ID:           user.ID,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.tokenService.AccessTokenTTL().Seconds()),
 	}, nil
 }
+
+// UpdateRole changes the role of a user, it applies to tokens issued from then on
+func (s *userService) UpdateRole(ctx context.Context, id int, role string) *utils.AppError {
+	if !models.IsValidRole(role) {
+		return utils.New(http.StatusBadRequest, "Invalid role, expected admin, rate_manager or viewer")
+	}
+
+	err := s.userRepo.UpdateRole(ctx, id, role)
+	if err != nil {
+		if errors.Is(err, utils.ErrCodeNotFound) {
+			return utils.New(http.StatusNotFound, "User not found")
+		}
+		return utils.New(http.StatusInternalServerError, "Failed to update user role")
+	}
+	return nil
+}
+
+// BootstrapAdmin creates an admin user, or promotes the user with that email
+// to admin if it already exists, so running it twice is harmless
+func (s *userService) BootstrapAdmin(ctx context.Context, email string, password string) (int, error) {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err == nil {
+		if err := s.userRepo.UpdateRole(ctx, user.ID, models.RoleAdmin); err != nil {
+			return 0, err
+		}
+		return user.ID, nil
+	}
+
+	hashedPassword, err := security.HashPassword(password)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.userRepo.CreateUser(ctx, &models.User{
+		Email:        email,
+		PasswordHash: hashedPassword,
+		Role:         models.RoleAdmin,
+	})
+}
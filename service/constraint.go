@@ -0,0 +1,21 @@
+package service
+
+import (
+	"currency-converter/utils"
+	"errors"
+	"net/http"
+)
+
+// constraintError maps a constraint violation from a repository to a client error,
+// conflict is the message for a duplicate. It returns nil for any other error
+func constraintError(err error, conflict string) *utils.AppError {
+	switch {
+	case errors.Is(err, utils.ErrCodeConflict):
+		return utils.New(http.StatusConflict, conflict)
+	case errors.Is(err, utils.ErrCodeInvalidReference):
+		return utils.New(http.StatusBadRequest, "referenced currency does not exist")
+	case errors.Is(err, utils.ErrCodeConstraint):
+		return utils.New(http.StatusBadRequest, "rate must be greater than zero and between two different currencies")
+	}
+	return nil
+}
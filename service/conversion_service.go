@@ -2,32 +2,148 @@ package service
 
 import (
 	"context"
+	"currency-converter/config"
+	"currency-converter/decimal"
 	"currency-converter/dto"
+	"currency-converter/models"
 	"currency-converter/utils"
 	"net/http"
+	"sort"
+	"time"
 )
 
 type conversionService struct {
 	currencyRepo     CurrencyRepository
 	exchangeRateRepo ExchangeRateRepository
+	pivotCurrency    string
+	moneyCfg         config.MoneyConfig
 }
 
-func NewConversionService(currencyRepo CurrencyRepository, exchangeRateRepo ExchangeRateRepository) *conversionService {
+func NewConversionService(
+	currencyRepo CurrencyRepository,
+	exchangeRateRepo ExchangeRateRepository,
+	pivotCurrency string,
+	moneyCfg config.MoneyConfig,
+) *conversionService {
 	return &conversionService{
 		currencyRepo:     currencyRepo,
 		exchangeRateRepo: exchangeRateRepo,
+		pivotCurrency:    pivotCurrency,
+		moneyCfg:         moneyCfg,
 	}
 }
 
+// ConvertCurrency finds the legs the same way as the batch and multi target conversions,
+// direct pair first, then through the pivot, then through the whole rate graph
 func (s *conversionService) ConvertCurrency(ctx context.Context, cmd dto.ConversionCmd) (dto.ConversionResult, *utils.AppError) {
+	table, appErr := s.loadRateTable(ctx, cmd.At)
+	if appErr != nil {
+		return dto.ConversionResult{}, appErr
+	}
+	return s.convertWithTable(table, cmd)
+}
 
-	// validate that both currencies exist and are active
-	fromCurrency, err := s.currencyRepo.GetByCode(ctx, cmd.From)
+// loadRateTable loads every currency with the current rates, or with the rates in effect at the given moment
+func (s *conversionService) loadRateTable(ctx context.Context, at *time.Time) (*rateTable, *utils.AppError) {
+	currencies, err := s.currencyRepo.GetAll(ctx)
 	if err != nil {
-		return dto.ConversionResult{}, utils.New(http.StatusNotFound, "from currency not found")
+		return nil, utils.New(http.StatusInternalServerError, "error in fetching currencies")
 	}
-	toCurrency, err := s.currencyRepo.GetByCode(ctx, cmd.To)
+	exchangeRates, err := s.rateGraph(ctx, at)
 	if err != nil {
+		return nil, utils.New(http.StatusInternalServerError, "error in fetching exchange rates")
+	}
+	return newRateTable(currencies, exchangeRates), nil
+}
+
+// rateGraph returns every current rate, or every rate in effect at the given moment
+func (s *conversionService) rateGraph(ctx context.Context, at *time.Time) ([]models.ExchangeRate, error) {
+	if at == nil {
+		return s.exchangeRateRepo.GetActiveExchangeRates(ctx)
+	}
+	return s.exchangeRateRepo.GetExchangeRatesAt(ctx, *at)
+}
+
+// ConvertBatch converts every item with one load of the currencies and rates,
+// each item gets either a result or its own error
+func (s *conversionService) ConvertBatch(ctx context.Context, items []dto.ConversionCmd, at *time.Time) ([]dto.BatchConversionResult, *utils.AppError) {
+	table, appErr := s.loadRateTable(ctx, at)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	results := make([]dto.BatchConversionResult, 0, len(items))
+	for _, item := range items {
+		result, appErr := s.convertWithTable(table, item)
+		results = append(results, dto.BatchConversionResult{Result: result, Err: appErr})
+	}
+	return results, nil
+}
+
+// ConvertToMany converts one amount into every target, nil targets means every
+// active currency. Targets which can't be converted are listed as missing
+func (s *conversionService) ConvertToMany(ctx context.Context, from string, amount decimal.Decimal, targets []string, at *time.Time) (dto.MultiConversionResult, *utils.AppError) {
+	table, appErr := s.loadRateTable(ctx, at)
+	if appErr != nil {
+		return dto.MultiConversionResult{}, appErr
+	}
+
+	// a bad source fails the whole call
+	fromCurrency, ok := table.currencies[from]
+	if !ok {
+		return dto.MultiConversionResult{}, utils.New(http.StatusNotFound, "from currency not found")
+	}
+	if !fromCurrency.IsActive {
+		return dto.MultiConversionResult{}, utils.New(http.StatusBadRequest, "from currency is inactive")
+	}
+
+	if targets == nil {
+		for _, currency := range table.currencies {
+			if currency.IsActive && currency.Code != from {
+				targets = append(targets, currency.Code)
+			}
+		}
+		sort.Strings(targets)
+	}
+
+	result := dto.MultiConversionResult{
+		RoundingMode: s.moneyCfg.RoundingMode,
+		Missing:      []dto.MissingTarget{},
+	}
+	for _, target := range targets {
+		if target == from {
+			result.Missing = append(result.Missing, dto.MissingTarget{Code: target, Reason: "same as from currency"})
+			continue
+		}
+
+		converted, appErr := s.convertWithTable(table, dto.ConversionCmd{From: from, To: target, Amount: amount, At: at})
+		if appErr != nil {
+			result.Missing = append(result.Missing, dto.MissingTarget{Code: target, Reason: appErr.Message})
+			continue
+		}
+		result.Conversions = append(result.Conversions, dto.TargetConversion{
+			To:                 target,
+			Rate:               converted.Rate,
+			ConvertedAmount:    converted.ConvertedAmount,
+			RawConvertedAmount: converted.RawConvertedAmount,
+			CashAmount:         converted.CashAmount,
+			MinorUnits:         converted.MinorUnits,
+			Legs:               converted.Legs,
+		})
+	}
+	return result, nil
+}
+
+// convertWithTable is the one conversion path, a single conversion, a batch and the
+// multi target conversion all resolve their legs against a rate table
+func (s *conversionService) convertWithTable(table *rateTable, cmd dto.ConversionCmd) (dto.ConversionResult, *utils.AppError) {
+	// validate that both currencies exist and are active
+	fromCurrency, ok := table.currencies[cmd.From]
+	if !ok {
+		return dto.ConversionResult{}, utils.New(http.StatusNotFound, "from currency not found")
+	}
+	toCurrency, ok := table.currencies[cmd.To]
+	if !ok {
 		return dto.ConversionResult{}, utils.New(http.StatusNotFound, "to currency not found")
 	}
 
@@ -39,18 +155,45 @@ func (s *conversionService) ConvertCurrency(ctx context.Context, cmd dto.Convers
 		return dto.ConversionResult{}, utils.New(http.StatusBadRequest, "to currency is inactive")
 	}
 
-	// fetch the exchange rate from exchange_rates table
-	exchangeRate, err := s.exchangeRateRepo.GetExchangeRateBetweenCurrencies(ctx, fromCurrency.ID, toCurrency.ID)
-	if err != nil {
+	// composed rate is the product of every leg rate,
+	// convertedAmount = amount * rate, rounded once at the very end
+	legs, ok := table.legs(fromCurrency, toCurrency, s.pivotCurrency)
+	if !ok {
 		return dto.ConversionResult{}, utils.New(http.StatusNotFound, "exchange rate not found or inactive")
 	}
-	
-	// convertedAmount = amount * rate
-	convertedAmount := cmd.Amount * exchangeRate.Rate
 
-	// return convertedAmount and rate
-	return dto.ConversionResult{
-		ConvertedAmount: convertedAmount,
-		Rate:            exchangeRate.Rate,
-	}, nil
+	return s.compose(cmd.Amount, legs, toCurrency), nil
+}
+
+// compose multiplies the leg rates and converts the amount, rounding once at the very end
+// to the minor units of the target currency
+func (s *conversionService) compose(amount decimal.Decimal, legs []dto.ConversionLeg, toCurrency models.Currency) dto.ConversionResult {
+	rate := decimal.NewFromInt(1)
+	for _, leg := range legs {
+		rate = rate.Mul(leg.Rate)
+	}
+
+	// currencies without minor units fall back to the configured scale
+	scale := s.moneyCfg.RoundingScale
+	if toCurrency.MinorUnits != nil {
+		scale = *toCurrency.MinorUnits
+	}
+
+	raw := amount.Mul(rate)
+	result := dto.ConversionResult{
+		ConvertedAmount:    raw.Round(scale, s.moneyCfg.RoundingMode),
+		RawConvertedAmount: raw,
+		Rate:               rate,
+		RoundingMode:       s.moneyCfg.RoundingMode,
+		MinorUnits:         scale,
+		Legs:               legs,
+	}
+
+	// cash amounts go to the nearest increment, e.g. CHF 0.05
+	if toCurrency.CashRoundingIncrement != nil {
+		cashAmount := raw.RoundToIncrement(*toCurrency.CashRoundingIncrement, s.moneyCfg.RoundingMode)
+		result.CashAmount = &cashAmount
+	}
+
+	return result
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"currency-converter/decimal"
 	"currency-converter/dto"
 	"currency-converter/models"
 	"currency-converter/utils"
@@ -14,8 +15,10 @@ type CurrencyRepository interface {
 	Create(ctx context.Context, currency *models.Currency) (*models.Currency, error)
 	GetByID(ctx context.Context, id int) (*models.Currency, error)
 	GetAll(ctx context.Context) ([]models.Currency, error)
-	Update(ctx context.Context, id int, input dto.CurrencyUpdateRequest) error
-	Delete(ctx context.Context, id int) error
+	List(ctx context.Context, filter dto.CurrencyFilter) ([]models.Currency, int64, string, error)
+	Update(ctx context.Context, id int, input dto.CurrencyUpdateRequest) (int64, error)
+	Delete(ctx context.Context, id int) (int64, error)
+	Restore(ctx context.Context, id int, restoreRates bool) (*models.Currency, int64, error)
 	GetByCode(ctx context.Context, code string) (models.Currency, error)
 }
 
@@ -31,12 +34,21 @@ func NewCurrencyService(currencyRepo CurrencyRepository) *currencyService {
 
 func (s *currencyService) CreateCurrency(ctx context.Context, req dto.CurrencyRequest) (*models.Currency, *utils.AppError) {
 	currency := &models.Currency{
-		Code:   strings.ToUpper(req.Code),
-		Name:   req.Name,
-		Symbol: req.Symbol,
+		Code:                  strings.ToUpper(req.Code),
+		Name:                  req.Name,
+		Symbol:                req.Symbol,
+		NumericCode:           req.NumericCode,
+		MinorUnits:            req.MinorUnits,
+		CashRoundingIncrement: req.CashRoundingIncrement,
+	}
+	if appErr := validateCashRoundingIncrement(req.CashRoundingIncrement); appErr != nil {
+		return nil, appErr
 	}
 	createdCurrency, err := s.currencyRepo.Create(ctx, currency)
 	if err != nil {
+		if appErr := constraintError(err, "currency "+currency.Code+" already exists"); appErr != nil {
+			return nil, appErr
+		}
 		return nil, utils.New(http.StatusInternalServerError, "error in creating currency")
 	}
 	return createdCurrency, nil
@@ -50,34 +62,74 @@ func (s *currencyService) GetCurrencyByID(ctx context.Context, id int) (*models.
 	return currency, nil
 }
 
-func (s *currencyService) GetAllCurrencies(ctx context.Context) ([]models.Currency, *utils.AppError) {
+// ListCurrencies returns one page of currencies, the total matching the filter and the next cursor
+func (s *currencyService) ListCurrencies(ctx context.Context, filter dto.CurrencyFilter) ([]models.Currency, int64, string, *utils.AppError) {
 
-	currencies, err := s.currencyRepo.GetAll(ctx)
+	currencies, total, next, err := s.currencyRepo.List(ctx, filter)
 	if err != nil {
-		return nil, utils.New(http.StatusInternalServerError, "error in fetching currencies")
+		return nil, 0, "", utils.New(http.StatusInternalServerError, "error in fetching currencies")
 	}
 
-	return currencies, nil
+	return currencies, total, next, nil
 }
 
-func (s *currencyService) UpdateCurrency(ctx context.Context, id int, req dto.CurrencyUpdateRequest) *utils.AppError {
+// UpdateCurrency returns how many rates were deactivated along with the currency,
+// or reactivated along with it when RestoreRates is set
+func (s *currencyService) UpdateCurrency(ctx context.Context, id int, req dto.CurrencyUpdateRequest) (int64, *utils.AppError) {
+	if appErr := validateCashRoundingIncrement(req.CashRoundingIncrement); appErr != nil {
+		return 0, appErr
+	}
+
+	if req.RestoreRates && (req.IsActive == nil || !*req.IsActive) {
+		return 0, utils.New(http.StatusBadRequest, "restore_rates needs is_active true")
+	}
 
-	err := s.currencyRepo.Update(ctx, id, req)
+	cascaded, err := s.currencyRepo.Update(ctx, id, req)
 	if err != nil {
 		if errors.Is(err, utils.ErrCodeNotFound) {
-			return utils.New(http.StatusNotFound, "currency not found")
+			return 0, utils.New(http.StatusNotFound, "currency not found")
 		}
-		return utils.New(http.StatusInternalServerError, "error in updating currency")
+		if appErr := constraintError(err, "currency already exists"); appErr != nil {
+			return 0, appErr
+		}
+		return 0, utils.New(http.StatusInternalServerError, "error in updating currency")
 	}
 
-	return nil
+	return cascaded, nil
+}
+
+// DeleteCurrency returns how many rates were deleted along with the currency
+func (s *currencyService) DeleteCurrency(ctx context.Context, id int) (int64, *utils.AppError) {
+
+	deleted, err := s.currencyRepo.Delete(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrCodeNotFound) {
+			return 0, utils.New(http.StatusNotFound, "currency not found")
+		}
+		return 0, utils.New(http.StatusInternalServerError, "error in deleting currency")
+	}
+	return deleted, nil
 }
 
-func (s *currencyService) DeleteCurrency(ctx context.Context, id int) *utils.AppError {
+// RestoreCurrency undeletes a currency, and the rates deleted with it when restoreRates is set
+func (s *currencyService) RestoreCurrency(ctx context.Context, id int, restoreRates bool) (*models.Currency, int64, *utils.AppError) {
 
-	err := s.currencyRepo.Delete(ctx, id)
+	currency, restored, err := s.currencyRepo.Restore(ctx, id, restoreRates)
 	if err != nil {
-		return utils.New(http.StatusInternalServerError, "error in deleting currency")
+		if errors.Is(err, utils.ErrCodeNotFound) {
+			return nil, 0, utils.New(http.StatusNotFound, "deleted currency not found")
+		}
+		if errors.Is(err, utils.ErrCodeConflict) {
+			return nil, 0, utils.New(http.StatusConflict, "another currency with this code exists")
+		}
+		return nil, 0, utils.New(http.StatusInternalServerError, "error in restoring currency")
+	}
+	return currency, restored, nil
+}
+
+func validateCashRoundingIncrement(increment *decimal.Decimal) *utils.AppError {
+	if increment != nil && increment.Sign() <= 0 {
+		return utils.New(http.StatusBadRequest, "cash_rounding_increment must be positive")
 	}
 	return nil
 }
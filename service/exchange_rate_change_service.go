@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"currency-converter/decimal"
+	"currency-converter/dto"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"errors"
+	"net/http"
+)
+
+type ExchangeRateChangeRepository interface {
+	Create(ctx context.Context, change *models.ExchangeRateChange) (*models.ExchangeRateChange, error)
+	GetByID(ctx context.Context, id int) (*models.ExchangeRateChange, error)
+	GetAll(ctx context.Context, status string) ([]models.ExchangeRateChange, error)
+	Review(ctx context.Context, id int, from string, to string, reviewerID int) error
+	Approve(ctx context.Context, id int, reviewerID int, quarantined *models.QuarantinedRate) (*models.ExchangeRateChange, error)
+}
+
+// ExchangeRateChecker validates proposed rates. The guards return the quarantined
+// rate to store when the rate guard holds an approved rate back
+type ExchangeRateChecker interface {
+	ValidateNewExchangeRate(ctx context.Context, req dto.ExchangeRateRequest) (*models.ExchangeRate, *utils.AppError)
+	GuardManualRate(ctx context.Context, id int, rate decimal.Decimal) (*models.QuarantinedRate, *utils.AppError)
+	GuardNewManualRate(ctx context.Context, fromCurrencyID int, toCurrencyID int, rate decimal.Decimal) (*models.QuarantinedRate, *utils.AppError)
+}
+
+type exchangeRateChangeService struct {
+	repo             ExchangeRateChangeRepository
+	exchangeRateRepo ExchangeRateRepository
+	checker          ExchangeRateChecker
+}
+
+func NewExchangeRateChangeService(
+	repo ExchangeRateChangeRepository,
+	exchangeRateRepo ExchangeRateRepository,
+	checker ExchangeRateChecker,
+) *exchangeRateChangeService {
+	return &exchangeRateChangeService{
+		repo:             repo,
+		exchangeRateRepo: exchangeRateRepo,
+		checker:          checker,
+	}
+}
+
+// ProposeChangeByPair is ProposeChange for the rate between two currencies given by ISO code
+func (s *exchangeRateChangeService) ProposeChangeByPair(ctx context.Context, fromCode string, toCode string, proposerID int, req dto.ExchangeRateUpdateRequest) (*models.ExchangeRateChange, *utils.AppError) {
+	exchangeRate, err := s.exchangeRateRepo.GetByPair(ctx, fromCode, toCode)
+	if err != nil {
+		return nil, utils.New(http.StatusNotFound, "exchange rate not found")
+	}
+	return s.ProposeChange(ctx, exchangeRate.ID, proposerID, req)
+}
+
+func (s *exchangeRateChangeService) ProposeChange(ctx context.Context, exchangeRateID int, proposerID int, req dto.ExchangeRateUpdateRequest) (*models.ExchangeRateChange, *utils.AppError) {
+	if _, err := s.exchangeRateRepo.GetByID(ctx, exchangeRateID); err != nil {
+		return nil, utils.New(http.StatusNotFound, "exchange rate not found")
+	}
+
+	return s.propose(ctx, &models.ExchangeRateChange{
+		Action:         models.ChangeActionUpdate,
+		ExchangeRateID: &exchangeRateID,
+		Rate:           req.Rate,
+		IsActive:       req.IsActive,
+		ProposedBy:     proposerID,
+	})
+}
+
+// ProposeCreate records a new rate, the pair is checked now and again when it is approved
+func (s *exchangeRateChangeService) ProposeCreate(ctx context.Context, proposerID int, req dto.ExchangeRateRequest) (*models.ExchangeRateChange, *utils.AppError) {
+	exchangeRate, appErr := s.checker.ValidateNewExchangeRate(ctx, req)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return s.propose(ctx, &models.ExchangeRateChange{
+		Action:         models.ChangeActionCreate,
+		FromCurrencyID: &exchangeRate.FromCurrencyID,
+		ToCurrencyID:   &exchangeRate.ToCurrencyID,
+		Rate:           &exchangeRate.Rate,
+		ProposedBy:     proposerID,
+	})
+}
+
+// ProposeDelete records the deletion of a rate, it stays live until another user approves it
+func (s *exchangeRateChangeService) ProposeDelete(ctx context.Context, exchangeRateID int, proposerID int) (*models.ExchangeRateChange, *utils.AppError) {
+	if _, err := s.exchangeRateRepo.GetByID(ctx, exchangeRateID); err != nil {
+		return nil, utils.New(http.StatusNotFound, "exchange rate not found")
+	}
+
+	return s.propose(ctx, &models.ExchangeRateChange{
+		Action:         models.ChangeActionDelete,
+		ExchangeRateID: &exchangeRateID,
+		ProposedBy:     proposerID,
+	})
+}
+
+func (s *exchangeRateChangeService) propose(ctx context.Context, change *models.ExchangeRateChange) (*models.ExchangeRateChange, *utils.AppError) {
+	change, err := s.repo.Create(ctx, change)
+	if err != nil {
+		return nil, utils.New(http.StatusInternalServerError, "error in proposing exchange rate change")
+	}
+	return change, nil
+}
+
+func (s *exchangeRateChangeService) GetChanges(ctx context.Context, status string) ([]models.ExchangeRateChange, *utils.AppError) {
+	changes, err := s.repo.GetAll(ctx, status)
+	if err != nil {
+		return nil, utils.New(http.StatusInternalServerError, "error in fetching exchange rate changes")
+	}
+	return changes, nil
+}
+
+// ApproveChange applies the change, only a user other than the proposer may approve it.
+// Claiming the change and applying it happen in one transaction, either both or neither
+func (s *exchangeRateChangeService) ApproveChange(ctx context.Context, id int, reviewerID int) (*models.ExchangeRateChange, *utils.AppError) {
+	change, appErr := s.pendingChange(ctx, id, reviewerID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	// a rate which trips the rate guard is quarantined instead of applied, a new pair
+	// has no current rate and is only checked against the pair's bounds
+	var quarantined *models.QuarantinedRate
+	switch {
+	case change.Action == models.ChangeActionCreate:
+		quarantined, appErr = s.checker.GuardNewManualRate(ctx, *change.FromCurrencyID, *change.ToCurrencyID, *change.Rate)
+	case change.Action == models.ChangeActionUpdate && change.Rate != nil:
+		quarantined, appErr = s.checker.GuardManualRate(ctx, *change.ExchangeRateID, *change.Rate)
+	}
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	approved, err := s.repo.Approve(ctx, id, reviewerID, quarantined)
+	if err != nil {
+		if errors.Is(err, utils.ErrCodeStateChanged) {
+			return nil, utils.New(http.StatusConflict, "exchange rate change is already reviewed")
+		}
+		if errors.Is(err, utils.ErrCodeNotFound) {
+			return nil, utils.New(http.StatusNotFound, "exchange rate not found")
+		}
+		if errors.Is(err, utils.ErrCodeInvalidReference) {
+			return nil, utils.New(http.StatusConflict, "a currency of the exchange rate is deleted or inactive")
+		}
+		if appErr := constraintError(err, "exchange rate already exists"); appErr != nil {
+			return nil, appErr
+		}
+		return nil, utils.New(http.StatusInternalServerError, "error in approving exchange rate change")
+	}
+	return approved, nil
+}
+
+func (s *exchangeRateChangeService) RejectChange(ctx context.Context, id int, reviewerID int) *utils.AppError {
+	if _, appErr := s.pendingChange(ctx, id, reviewerID); appErr != nil {
+		return appErr
+	}
+
+	if err := s.repo.Review(ctx, id, models.ChangePending, models.ChangeRejected, reviewerID); err != nil {
+		if errors.Is(err, utils.ErrCodeStateChanged) {
+			return utils.New(http.StatusConflict, "exchange rate change is already reviewed")
+		}
+		return utils.New(http.StatusInternalServerError, "error in rejecting exchange rate change")
+	}
+	return nil
+}
+
+// pendingChange loads a change the reviewer is allowed to review
+func (s *exchangeRateChangeService) pendingChange(ctx context.Context, id int, reviewerID int) (*models.ExchangeRateChange, *utils.AppError) {
+	change, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, utils.New(http.StatusNotFound, "exchange rate change not found")
+	}
+	if change.Status != models.ChangePending {
+		return nil, utils.New(http.StatusConflict, "exchange rate change is already reviewed")
+	}
+	if change.ProposedBy == reviewerID {
+		return nil, utils.New(http.StatusForbidden, "an exchange rate change must be reviewed by a different user than the one who proposed it")
+	}
+	return change, nil
+}
@@ -2,58 +2,95 @@ package service
 
 import (
 	"context"
+	"currency-converter/config"
+	"currency-converter/decimal"
 	"currency-converter/dto"
 	"currency-converter/models"
+	"currency-converter/provider"
 	"currency-converter/utils"
-	"encoding/json"
 	"errors"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 )
 
 type ExchangeRateRepository interface {
 	Create(ctx context.Context, exchangeRate *models.ExchangeRate) (*models.ExchangeRate, error)
 	GetByID(ctx context.Context, id int) (*models.ExchangeRate, error)
+	GetByPair(ctx context.Context, fromCode string, toCode string) (*models.ExchangeRate, error)
 	GetAll(ctx context.Context) ([]models.ExchangeRate, error)
+	List(ctx context.Context, filter dto.ExchangeRateFilter) ([]models.ExchangeRate, int64, string, error)
 	Update(ctx context.Context, id int, req dto.ExchangeRateUpdateRequest) error
 	Delete(ctx context.Context, id int) error
 	GetExchangeRateBetweenCurrencies(ctx context.Context, fromCurrencyID int, toCurrencyID int) (models.ExchangeRate, error)
-	CreateOrUpdate(ctx context.Context, fromCurrencyID int, toCurrencyID int, rate float64) error
+	CreateOrUpdate(ctx context.Context, fromCurrencyID int, toCurrencyID int, rate decimal.Decimal, source string, quotes models.RateQuotes) error
+	GetActiveExchangeRates(ctx context.Context) ([]models.ExchangeRate, error)
+	GetExchangeRateBetweenCurrenciesAt(ctx context.Context, fromCurrencyID int, toCurrencyID int, at time.Time) (models.ExchangeRate, error)
+	GetExchangeRatesAt(ctx context.Context, at time.Time) ([]models.ExchangeRate, error)
+}
+
+type RateProvider interface {
+	Name() string
+	FetchRates(ctx context.Context, base string) ([]provider.Quote, error)
 }
 
 type exchangeRateService struct {
-	repo            ExchangeRateRepository
-	currencyRepo    CurrencyRepository
-	httpClient      *http.Client
-	exchangeRateAPI string
+	repo             ExchangeRateRepository
+	currencyRepo     CurrencyRepository
+	quarantineRepo   QuarantineRepository
+	rateProvider     RateProvider
+	targetCurrencies []string
+	guard            rateGuard
 }
 
 func NewExchangeRateService(
 	repo ExchangeRateRepository,
 	currencyRepo CurrencyRepository,
-	httpClient *http.Client,
-	exchangeRateAPI string,
+	quarantineRepo QuarantineRepository,
+	rateProvider RateProvider,
+	targetCurrencies []string,
+	guardCfg config.RateGuardConfig,
 ) *exchangeRateService {
 	return &exchangeRateService{
-		repo:            repo,
-		currencyRepo:    currencyRepo,
-		httpClient:      httpClient,
-		exchangeRateAPI: exchangeRateAPI,
+		repo:             repo,
+		currencyRepo:     currencyRepo,
+		quarantineRepo:   quarantineRepo,
+		rateProvider:     rateProvider,
+		targetCurrencies: targetCurrencies,
+		guard:            newRateGuard(guardCfg),
 	}
 }
 
-func (s *exchangeRateService) CreateExchangeRate(ctx context.Context, req dto.ExchangeRateRequest) (*models.ExchangeRate, *utils.AppError) {
-	exchangeRate := &models.ExchangeRate{
-		FromCurrencyID: req.FromCurrencyID,
-		ToCurrencyID:   req.ToCurrencyID,
-		Rate:           req.Rate,
+// ValidateNewExchangeRate checks a proposed rate for a new pair and returns it resolved to
+// currency ids, the rate itself is only created once the proposal is approved
+func (s *exchangeRateService) ValidateNewExchangeRate(ctx context.Context, req dto.ExchangeRateRequest) (*models.ExchangeRate, *utils.AppError) {
+	if req.Rate.Sign() <= 0 {
+		return nil, utils.New(http.StatusBadRequest, "rate must be greater than zero")
 	}
 
-	createdExchangeRate, err := s.repo.Create(ctx, exchangeRate)
-	if err != nil {
-		return nil, utils.New(http.StatusInternalServerError, "error in creating exchange rate")
+	// both currencies have to exist and be active, the foreign keys only catch missing ones
+	fromCurrency, appErr := s.resolveCurrency(ctx, req.FromCurrency, req.FromCurrencyID, "from")
+	if appErr != nil {
+		return nil, appErr
+	}
+	toCurrency, appErr := s.resolveCurrency(ctx, req.ToCurrency, req.ToCurrencyID, "to")
+	if appErr != nil {
+		return nil, appErr
+	}
+	if fromCurrency.ID == toCurrency.ID {
+		return nil, utils.New(http.StatusBadRequest, "from and to currency cannot be the same")
+	}
+	if _, err := s.repo.GetByPair(ctx, fromCurrency.Code, toCurrency.Code); err == nil {
+		return nil, utils.New(http.StatusConflict, "an exchange rate from "+fromCurrency.Code+" to "+toCurrency.Code+" already exists")
 	}
 
-	return createdExchangeRate, nil
+	return &models.ExchangeRate{
+		FromCurrencyID: fromCurrency.ID,
+		ToCurrencyID:   toCurrency.ID,
+		Rate:           req.Rate,
+		Source:         models.RateSourceManual,
+	}, nil
 }
 
 func (s *exchangeRateService) GetExchangeRateByID(ctx context.Context, id int) (*models.ExchangeRate, *utils.AppError) {
@@ -64,94 +101,240 @@ func (s *exchangeRateService) GetExchangeRateByID(ctx context.Context, id int) (
 	return exchangeRate, nil
 }
 
-func (s *exchangeRateService) GetAllExchangeRates(ctx context.Context) ([]models.ExchangeRate, *utils.AppError) {
-	exchangeRates, err := s.repo.GetAll(ctx)
+// GetExchangeRateByPair looks a rate up by the ISO codes of its currencies
+func (s *exchangeRateService) GetExchangeRateByPair(ctx context.Context, fromCode string, toCode string) (*models.ExchangeRate, *utils.AppError) {
+	exchangeRate, err := s.repo.GetByPair(ctx, fromCode, toCode)
 	if err != nil {
-		return nil, utils.New(http.StatusInternalServerError, "error in fetching all exchange rates")
+		return nil, utils.New(http.StatusNotFound, "exchange rate not found")
 	}
-	return exchangeRates, nil
+	return exchangeRate, nil
 }
 
-func (s *exchangeRateService) UpdateExchangeRate(ctx context.Context, id int, req dto.ExchangeRateUpdateRequest) *utils.AppError {
-
-	err := s.repo.Update(ctx, id, req)
-	if err != nil {
-		if errors.Is(err, utils.ErrCodeNotFound) {
-			return utils.New(http.StatusNotFound, "exchange rate not found")
+// resolveCurrency loads the active currency named by code, or by id when code is empty
+func (s *exchangeRateService) resolveCurrency(ctx context.Context, code string, id int, side string) (*models.Currency, *utils.AppError) {
+	var currency *models.Currency
+	switch {
+	case code != "":
+		byCode, err := s.currencyRepo.GetByCode(ctx, strings.ToUpper(code))
+		if err != nil {
+			return nil, utils.New(http.StatusBadRequest, side+" currency not found")
+		}
+		currency = &byCode
+	case id != 0:
+		byID, err := s.currencyRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, utils.New(http.StatusBadRequest, side+" currency not found")
 		}
-		return utils.New(http.StatusInternalServerError, "error in updating exchange rate")
+		currency = byID
+	default:
+		return nil, utils.New(http.StatusBadRequest, side+"_currency or "+side+"_currency_id is required")
 	}
 
-	return nil
+	if !currency.IsActive {
+		return nil, utils.New(http.StatusBadRequest, side+" currency is inactive")
+	}
+	return currency, nil
 }
 
-func (s *exchangeRateService) DeleteExchangeRate(ctx context.Context, id int) *utils.AppError {
-	_, err := s.repo.GetByID(ctx, id)
+// ListExchangeRates returns one page of exchange rates, the total matching the filter and the next cursor
+func (s *exchangeRateService) ListExchangeRates(ctx context.Context, filter dto.ExchangeRateFilter) ([]models.ExchangeRate, int64, string, *utils.AppError) {
+	exchangeRates, total, next, err := s.repo.List(ctx, filter)
 	if err != nil {
-		return utils.New(http.StatusNotFound, "exchange rate not found")
+		return nil, 0, "", utils.New(http.StatusInternalServerError, "error in fetching all exchange rates")
 	}
-	err = s.repo.Delete(ctx, id)
+	return exchangeRates, total, next, nil
+}
+
+// GuardManualRate checks a manual rate for the exchange rate against the rate guard. It returns
+// nil when the rate may be applied, otherwise the quarantined rate for the caller to store
+func (s *exchangeRateService) GuardManualRate(ctx context.Context, id int, rate decimal.Decimal) (*models.QuarantinedRate, *utils.AppError) {
+	current, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return utils.New(http.StatusInternalServerError, "error in deleting exchange rate")
+		return nil, utils.New(http.StatusNotFound, "exchange rate not found")
 	}
-	return nil
+	return s.guardManualRate(ctx, current.FromCurrencyID, current.ToCurrencyID, current, rate)
 }
 
-func (s *exchangeRateService) SyncExchangeRates(ctx context.Context, code string) *utils.AppError {
-	// validation done in controller
-
-	// build a get request to fetch exchange rates for the given code from the external API
-	url := s.exchangeRateAPI + "/" + code
+// GuardNewManualRate is GuardManualRate for a pair without a rate, like the sync of a new
+// pair only the pair's min and max bounds apply
+func (s *exchangeRateService) GuardNewManualRate(ctx context.Context, fromCurrencyID int, toCurrencyID int, rate decimal.Decimal) (*models.QuarantinedRate, *utils.AppError) {
+	return s.guardManualRate(ctx, fromCurrencyID, toCurrencyID, nil, rate)
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (s *exchangeRateService) guardManualRate(ctx context.Context, fromCurrencyID int, toCurrencyID int, current *models.ExchangeRate, rate decimal.Decimal) (*models.QuarantinedRate, *utils.AppError) {
+	fromCurrency, err := s.currencyRepo.GetByID(ctx, fromCurrencyID)
 	if err != nil {
-		return utils.New(http.StatusInternalServerError, "error in creating http request")
+		return nil, utils.New(http.StatusConflict, "from currency of the exchange rate is deleted")
 	}
-	resp, err := s.httpClient.Do(httpReq)
+	toCurrency, err := s.currencyRepo.GetByID(ctx, toCurrencyID)
 	if err != nil {
-		return utils.New(http.StatusInternalServerError, "error in making http request to exchange rate API")
+		return nil, utils.New(http.StatusConflict, "to currency of the exchange rate is deleted")
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return utils.New(http.StatusInternalServerError, "received non-200 response from exchange rate API")
+
+	var currentRate *decimal.Decimal
+	var currentID *int
+	if current != nil {
+		currentRate, currentID = &current.Rate, &current.ID
+	}
+	reason := s.guard.check(fromCurrency.Code, toCurrency.Code, currentRate, rate)
+	if reason == "" {
+		return nil, nil
+	}
+
+	return &models.QuarantinedRate{
+		ExchangeRateID: currentID,
+		FromCurrencyID: fromCurrencyID,
+		ToCurrencyID:   toCurrencyID,
+		Rate:           rate,
+		PreviousRate:   currentRate,
+		Source:         models.RateSourceManual,
+		Reason:         reason,
+	}, nil
+}
+
+func (s *exchangeRateService) SyncExchangeRates(ctx context.Context, code string) (dto.SyncResult, *utils.AppError) {
+	// validation done in controller
+	result := dto.SyncResult{
+		Base:    code,
+		Updated: []string{},
+		Skipped: []dto.SkippedCurrency{},
 	}
 
-	// parse the response and update the exchange rates in the database using the repo
-	var apiResponse dto.ExchangeRateExternalResponse
-	err = json.NewDecoder(resp.Body).Decode(&apiResponse)
+	currencies, err := s.currencyRepo.GetAll(ctx)
 	if err != nil {
-		return utils.New(http.StatusInternalServerError, "error in parsing response from exchange rate API")
+		return result, utils.New(http.StatusInternalServerError, "error in fetching currencies")
 	}
-	if apiResponse.Result != "success" {
-		return utils.New(http.StatusInternalServerError, "exchange rate API returned unsuccessful result")
+	currenciesByCode := make(map[string]models.Currency, len(currencies))
+	for _, currency := range currencies {
+		currenciesByCode[currency.Code] = currency
 	}
-	if apiResponse.BaseCode != code {
-		return utils.New(http.StatusInternalServerError, "exchange rate API returned data for unexpected base code")
+
+	fromCurrency, ok := currenciesByCode[code]
+	if !ok {
+		return result, utils.New(http.StatusNotFound, "base currency not found")
+	}
+	if !fromCurrency.IsActive {
+		return result, utils.New(http.StatusBadRequest, "base currency is inactive")
 	}
 
-	fromCurrency, err := s.currencyRepo.GetByCode(ctx, apiResponse.BaseCode)
+	// fetch the normalized quotes for the given code from the configured provider
+	quotes, err := s.rateProvider.FetchRates(ctx, code)
 	if err != nil {
-		return utils.New(http.StatusInternalServerError, "error in fetching from currency ID")
+		if errors.Is(err, provider.ErrUnsupportedBase) {
+			return result, utils.New(http.StatusBadRequest, "base currency not supported by exchange rate provider")
+		}
+		return result, utils.New(http.StatusInternalServerError, "error in fetching exchange rates from "+s.rateProvider.Name())
+	}
+
+	// current rates of the base, the guard compares every new rate against them
+	activeRates, err := s.repo.GetActiveExchangeRates(ctx)
+	if err != nil {
+		return result, utils.New(http.StatusInternalServerError, "error in fetching exchange rates")
+	}
+	currentRates := make(map[int]models.ExchangeRate)
+	for _, rate := range activeRates {
+		if rate.FromCurrencyID == fromCurrency.ID {
+			currentRates[rate.ToCurrencyID] = rate
+		}
 	}
 
-	toCurrencyCodes := []string{"USD", "INR", "EUR", "CAD", "JPY"}
+	rates := make(map[string]provider.Quote, len(quotes))
+	for _, quote := range quotes {
+		if quote.Base != code {
+			return result, utils.New(http.StatusInternalServerError, "exchange rate provider returned data for unexpected base code")
+		}
+		rates[quote.Quote] = quote
+		result.Source = quote.Source
+	}
 
-	for _, toCurrencyCode := range toCurrencyCodes {
-		rate, ok := apiResponse.ConversionRates[toCurrencyCode]
-		if !ok || toCurrencyCode == code {
-			continue // skip if the API response does not contain conversion rate for this currency code
+	for _, toCurrencyCode := range s.syncTargets(currencies) {
+		if toCurrencyCode == code {
+			continue
 		}
-		toCurrency, err := s.currencyRepo.GetByCode(ctx, toCurrencyCode)
-		if err != nil {
-			return utils.New(http.StatusInternalServerError, "error in fetching to currency ID")
+
+		toCurrency, ok := currenciesByCode[toCurrencyCode]
+		if !ok {
+			result.Skipped = append(result.Skipped, dto.SkippedCurrency{Code: toCurrencyCode, Reason: "unknown currency"})
+			continue
+		}
+		if !toCurrency.IsActive {
+			result.Skipped = append(result.Skipped, dto.SkippedCurrency{Code: toCurrencyCode, Reason: "currency is inactive"})
+			continue
+		}
+		quote, ok := rates[toCurrencyCode]
+		if !ok {
+			result.Skipped = append(result.Skipped, dto.SkippedCurrency{Code: toCurrencyCode, Reason: "not quoted by " + result.Source})
+			continue
 		}
 
-		// update the exchange rate in the database
-		err = s.repo.CreateOrUpdate(ctx, fromCurrency.ID, toCurrency.ID, rate)
+		// hold back implausible rates, the current rate stays active
+		var currentRate *decimal.Decimal
+		var currentID *int
+		if current, ok := currentRates[toCurrency.ID]; ok {
+			currentRate, currentID = &current.Rate, &current.ID
+		}
+		if reason := s.guard.check(code, toCurrencyCode, currentRate, quote.Rate); reason != "" {
+			_, err = s.quarantineRepo.Create(ctx, &models.QuarantinedRate{
+				ExchangeRateID: currentID,
+				FromCurrencyID: fromCurrency.ID,
+				ToCurrencyID:   toCurrency.ID,
+				Rate:           quote.Rate,
+				PreviousRate:   currentRate,
+				Source:         quote.Source,
+				Quotes:         toRateQuotes(quote.Contributions),
+				Reason:         reason,
+			})
+			if err != nil {
+				return result, utils.New(http.StatusInternalServerError, "error in quarantining exchange rate")
+			}
+			result.Skipped = append(result.Skipped, dto.SkippedCurrency{Code: toCurrencyCode, Reason: "quarantined: " + reason})
+			continue
+		}
+
+		// update the exchange rate in the database, recording which provider it came from
+		err = s.repo.CreateOrUpdate(ctx, fromCurrency.ID, toCurrency.ID, quote.Rate, quote.Source, toRateQuotes(quote.Contributions))
+		if errors.Is(err, utils.ErrCodeInvalidReference) {
+			// deleted or deactivated while the sync was running
+			result.Skipped = append(result.Skipped, dto.SkippedCurrency{Code: toCurrencyCode, Reason: "currency is deleted or inactive"})
+			continue
+		}
 		if err != nil {
-			return utils.New(http.StatusInternalServerError, "error in updating exchange rate in database")
+			return result, utils.New(http.StatusInternalServerError, "error in updating exchange rate in database")
+		}
+		result.Updated = append(result.Updated, toCurrencyCode)
+	}
+
+	return result, nil
+}
+
+// syncTargets is the configured allow-list, or every active currency when there is none
+func (s *exchangeRateService) syncTargets(currencies []models.Currency) []string {
+	if len(s.targetCurrencies) > 0 {
+		return s.targetCurrencies
+	}
+
+	targets := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		if currency.IsActive {
+			targets = append(targets, currency.Code)
 		}
 	}
+	sort.Strings(targets)
+	return targets
+}
 
-	return nil
+// toRateQuotes keeps the provider quotes behind a consensus rate, nil for a single provider
+func toRateQuotes(contributions []provider.Contribution) models.RateQuotes {
+	if len(contributions) == 0 {
+		return nil
+	}
+	quotes := make(models.RateQuotes, 0, len(contributions))
+	for _, c := range contributions {
+		quotes = append(quotes, models.RateQuote{
+			Source:   c.Source,
+			Rate:     c.Rate,
+			Accepted: c.Accepted,
+		})
+	}
+	return quotes
 }
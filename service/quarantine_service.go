@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"errors"
+	"net/http"
+)
+
+type QuarantineRepository interface {
+	Create(ctx context.Context, quarantined *models.QuarantinedRate) (*models.QuarantinedRate, error)
+	GetByID(ctx context.Context, id int) (*models.QuarantinedRate, error)
+	GetAll(ctx context.Context, status string) ([]models.QuarantinedRate, error)
+	Approve(ctx context.Context, id int, reviewerID int) error
+	Reject(ctx context.Context, id int, reviewerID int) error
+}
+
+type quarantineService struct {
+	repo QuarantineRepository
+}
+
+func NewQuarantineService(repo QuarantineRepository) *quarantineService {
+	return &quarantineService{
+		repo: repo,
+	}
+}
+
+func (s *quarantineService) GetQuarantinedRates(ctx context.Context, status string) ([]models.QuarantinedRate, *utils.AppError) {
+	quarantined, err := s.repo.GetAll(ctx, status)
+	if err != nil {
+		return nil, utils.New(http.StatusInternalServerError, "error in fetching quarantined rates")
+	}
+	return quarantined, nil
+}
+
+// ApproveQuarantinedRate puts the held back rate live, the same way it would have gone live without the guard
+func (s *quarantineService) ApproveQuarantinedRate(ctx context.Context, id int, reviewerID int) *utils.AppError {
+	err := s.repo.Approve(ctx, id, reviewerID)
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(err, utils.ErrCodeNotFound):
+		return utils.New(http.StatusNotFound, "quarantined rate or its exchange rate not found")
+	case errors.Is(err, utils.ErrCodeStateChanged):
+		return utils.New(http.StatusConflict, "quarantined rate is already reviewed")
+	case errors.Is(err, utils.ErrCodeOutdated):
+		return utils.New(http.StatusConflict, "exchange rate changed after the rate was quarantined, reject it instead")
+	case errors.Is(err, utils.ErrCodeInvalidReference):
+		return utils.New(http.StatusBadRequest, "currency of the quarantined rate is deleted or inactive")
+	}
+	return utils.New(http.StatusInternalServerError, "error in applying quarantined rate")
+}
+
+func (s *quarantineService) RejectQuarantinedRate(ctx context.Context, id int, reviewerID int) *utils.AppError {
+	err := s.repo.Reject(ctx, id, reviewerID)
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(err, utils.ErrCodeNotFound):
+		return utils.New(http.StatusNotFound, "quarantined rate not found")
+	case errors.Is(err, utils.ErrCodeStateChanged):
+		return utils.New(http.StatusConflict, "quarantined rate is already reviewed")
+	}
+	return utils.New(http.StatusInternalServerError, "error in rejecting quarantined rate")
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"currency-converter/config"
+	"currency-converter/decimal"
+	"fmt"
+)
+
+// rateGuard decides whether a new rate is plausible enough to go live
+type rateGuard struct {
+	cfg config.RateGuardConfig
+}
+
+func newRateGuard(cfg config.RateGuardConfig) rateGuard {
+	return rateGuard{cfg: cfg}
+}
+
+// check returns why the new rate is suspicious, or an empty string if it is fine,
+// current is nil when the pair has no active rate yet
+func (g rateGuard) check(fromCode, toCode string, current *decimal.Decimal, next decimal.Decimal) string {
+	if next.Sign() <= 0 {
+		return "rate must be greater than zero"
+	}
+
+	limit := g.cfg.Pairs[fromCode+"/"+toCode]
+	if limit.MinRate != nil && next.Cmp(*limit.MinRate) < 0 {
+		return fmt.Sprintf("rate %s is below the minimum %s", next, limit.MinRate)
+	}
+	if limit.MaxRate != nil && next.Cmp(*limit.MaxRate) > 0 {
+		return fmt.Sprintf("rate %s is above the maximum %s", next, limit.MaxRate)
+	}
+
+	maxMove := limit.MaxMovePct
+	if maxMove == nil {
+		maxMove = g.cfg.DefaultMaxMovePct
+	}
+	if maxMove == nil || current == nil || current.Sign() <= 0 {
+		return ""
+	}
+
+	// move in percent = |next - current| / current * 100
+	move, _ := next.Sub(*current).Abs().Mul(decimal.NewFromInt(100)).Div(*current)
+	if move.Cmp(*maxMove) > 0 {
+		return fmt.Sprintf("rate moved %s%% from %s, limit is %s%%", move.Round(2, decimal.HalfUp), current, maxMove)
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"currency-converter/decimal"
+	"currency-converter/dto"
+	"currency-converter/models"
+)
+
+// rateTable is an in-memory snapshot of the currencies and rates which every conversion
+// resolves its legs against: direct pair, then the pivot, then the shortest path.
+// Every rate can also be used the other way round, as its reciprocal
+type rateTable struct {
+	currencies  map[string]models.Currency // by code
+	activeCodes map[int]string             // code of every active currency by id
+	pairs       map[[2]int]rateEdge        // stored rates and their reciprocals by from and to id
+	graph       map[int][]rateEdge         // edges between active currencies by from id
+}
+
+// rateEdge converts from one currency to another, an inverted edge uses 1/rate
+// of the rate stored in the other direction
+type rateEdge struct {
+	fromID, toID int
+	rate         decimal.Decimal
+	inverted     bool
+}
+
+func newRateTable(currencies []models.Currency, exchangeRates []models.ExchangeRate) *rateTable {
+	t := &rateTable{
+		currencies:  make(map[string]models.Currency, len(currencies)),
+		activeCodes: make(map[int]string, len(currencies)),
+		pairs:       make(map[[2]int]rateEdge, 2*len(exchangeRates)),
+		graph:       make(map[int][]rateEdge),
+	}
+
+	for _, currency := range currencies {
+		t.currencies[currency.Code] = currency
+		if currency.IsActive {
+			t.activeCodes[currency.ID] = currency.Code
+		}
+	}
+
+	// stored rates first, a reciprocal only fills in a direction which has no rate of its own
+	for _, rate := range exchangeRates {
+		t.add(rateEdge{fromID: rate.FromCurrencyID, toID: rate.ToCurrencyID, rate: rate.Rate})
+	}
+	for _, rate := range exchangeRates {
+		if _, ok := t.pairs[[2]int{rate.ToCurrencyID, rate.FromCurrencyID}]; ok || rate.Rate.Sign() <= 0 {
+			continue
+		}
+		inverse, _ := decimal.NewFromInt(1).Div(rate.Rate)
+		t.add(rateEdge{fromID: rate.ToCurrencyID, toID: rate.FromCurrencyID, rate: inverse, inverted: true})
+	}
+
+	return t
+}
+
+func (t *rateTable) add(edge rateEdge) {
+	t.pairs[[2]int{edge.fromID, edge.toID}] = edge
+
+	_, fromOk := t.activeCodes[edge.fromID]
+	_, toOk := t.activeCodes[edge.toID]
+	if fromOk && toOk {
+		t.graph[edge.fromID] = append(t.graph[edge.fromID], edge)
+	}
+}
+
+func (t *rateTable) leg(edge rateEdge) dto.ConversionLeg {
+	return dto.ConversionLeg{
+		From:     t.activeCodes[edge.fromID],
+		To:       t.activeCodes[edge.toID],
+		Rate:     edge.rate,
+		Inverted: edge.inverted,
+	}
+}
+
+// legs finds the legs from one active currency to another, false if they aren't connected
+func (t *rateTable) legs(fromCurrency, toCurrency models.Currency, pivotCode string) ([]dto.ConversionLeg, bool) {
+	// direct pair
+	if edge, ok := t.pairs[[2]int{fromCurrency.ID, toCurrency.ID}]; ok {
+		return []dto.ConversionLeg{t.leg(edge)}, true
+	}
+
+	// from -> pivot -> to
+	if pivot, ok := t.currencies[pivotCode]; ok && pivot.IsActive && pivotCode != fromCurrency.Code && pivotCode != toCurrency.Code {
+		firstLeg, firstOk := t.pairs[[2]int{fromCurrency.ID, pivot.ID}]
+		secondLeg, secondOk := t.pairs[[2]int{pivot.ID, toCurrency.ID}]
+		if firstOk && secondOk {
+			return []dto.ConversionLeg{t.leg(firstLeg), t.leg(secondLeg)}, true
+		}
+	}
+
+	// shortest path through the active rate graph
+	return t.shortestPath(fromCurrency.ID, toCurrency.ID)
+}
+
+// shortestPath runs a breadth first search over the rates,
+// only walking through currencies which are themselves active
+func (t *rateTable) shortestPath(fromID, toID int) ([]dto.ConversionLeg, bool) {
+	// previous holds the edge used to reach each visited currency
+	previous := map[int]rateEdge{}
+	visited := map[int]bool{fromID: true}
+	queue := []int{fromID}
+
+	for len(queue) > 0 && !visited[toID] {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range t.graph[current] {
+			if visited[edge.toID] {
+				continue
+			}
+			visited[edge.toID] = true
+			previous[edge.toID] = edge
+			queue = append(queue, edge.toID)
+		}
+	}
+
+	if !visited[toID] {
+		return nil, false
+	}
+
+	// walk back from the target to build the legs in order
+	var legs []dto.ConversionLeg
+	for id := toID; id != fromID; {
+		edge := previous[id]
+		legs = append([]dto.ConversionLeg{t.leg(edge)}, legs...)
+		id = edge.fromID
+	}
+
+	return legs, true
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"currency-converter/dto"
+	"currency-converter/models"
+	"currency-converter/utils"
+	"log"
+	"net/http"
+	"time"
+)
+
+type SyncRunRepository interface {
+	Save(ctx context.Context, run *models.SyncRun) error
+	GetAll(ctx context.Context) ([]models.SyncRun, error)
+	WithAdvisoryLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+type Syncer interface {
+	SyncExchangeRates(ctx context.Context, code string) (dto.SyncResult, *utils.AppError)
+}
+
+type syncRunService struct {
+	repo   SyncRunRepository
+	syncer Syncer
+}
+
+func NewSyncRunService(repo SyncRunRepository, syncer Syncer) *syncRunService {
+	return &syncRunService{
+		repo:   repo,
+		syncer: syncer,
+	}
+}
+
+func (s *syncRunService) GetSyncRuns(ctx context.Context) ([]models.SyncRun, *utils.AppError) {
+	runs, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, utils.New(http.StatusInternalServerError, "error in fetching sync runs")
+	}
+	return runs, nil
+}
+
+// SyncExchangeRates syncs one base currency on request, under the advisory lock of the
+// scheduled sync so that it doesn't race the scheduler of any replica
+func (s *syncRunService) SyncExchangeRates(ctx context.Context, code string) (dto.SyncResult, *utils.AppError) {
+	var result dto.SyncResult
+	var appErr *utils.AppError
+
+	acquired, err := s.repo.WithAdvisoryLock(ctx, models.SyncLockKey, func(ctx context.Context) error {
+		result, appErr = s.syncBase(ctx, code)
+		return nil
+	})
+	if err != nil {
+		return result, utils.New(http.StatusInternalServerError, "error in taking the sync lock")
+	}
+	if !acquired {
+		return result, utils.New(http.StatusConflict, "a sync is already running, try again later")
+	}
+	return result, appErr
+}
+
+// SyncBases syncs every base currency in turn under the advisory lock, it reports false
+// without syncing when another replica holds the lock. A failed base doesn't stop the others
+func (s *syncRunService) SyncBases(ctx context.Context, bases []string) (bool, error) {
+	return s.repo.WithAdvisoryLock(ctx, models.SyncLockKey, func(ctx context.Context) error {
+		for _, base := range bases {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.syncBase(ctx, base)
+		}
+		return nil
+	})
+}
+
+// syncBase syncs one base currency and records the run, it must be called under the sync lock
+func (s *syncRunService) syncBase(ctx context.Context, code string) (dto.SyncResult, *utils.AppError) {
+	start := time.Now()
+	result, appErr := s.syncer.SyncExchangeRates(ctx, code)
+
+	run := &models.SyncRun{
+		BaseCode:   code,
+		LastRunAt:  start,
+		DurationMs: time.Since(start).Milliseconds(),
+		Status:     models.SyncStatusSuccess,
+	}
+	if appErr != nil {
+		run.Status = models.SyncStatusFailed
+		run.Error = appErr.Message
+		log.Printf("sync of %s failed: %s", code, appErr.Message)
+	}
+
+	// the run is recorded even if ctx got cancelled mid sync
+	if err := s.repo.Save(context.WithoutCancel(ctx), run); err != nil {
+		log.Printf("error in saving sync run of %s: %v", code, err)
+	}
+	return result, appErr
+}
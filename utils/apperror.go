@@ -4,6 +4,16 @@ import "errors"
 
 var (
 	ErrCodeNotFound = errors.New("record not found")
+
+	// a conditional status change found the record in another state, e.g. already reviewed
+	ErrCodeStateChanged = errors.New("record is not in the expected state anymore")
+	// the record was overtaken by a newer write, e.g. a quarantined rate older than the current rate
+	ErrCodeOutdated = errors.New("record is older than the current state")
+
+	// constraint violations, as translated by the repositories
+	ErrCodeConflict         = errors.New("record already exists")
+	ErrCodeInvalidReference = errors.New("referenced record does not exist")
+	ErrCodeConstraint       = errors.New("value violates a check constraint")
 )
 
 type AppError struct {
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// Cursor is the position after the last row of a page, in a given sort order
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"` // sort column of the last row, empty when sorting by id
+	ID    int    `json:"id"`          // tie breaker for rows with the same value
+}
+
+// EncodeCursor turns a cursor into the opaque token handed to clients
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+	return cursor, nil
+}
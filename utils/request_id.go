@@ -0,0 +1,17 @@
+package utils
+
+import "context"
+
+type requestIDCtxKeyType struct{}
+
+var requestIDCtxKey = requestIDCtxKeyType{}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey, requestID)
+}
+
+// RequestIDFromContext returns the id of the request being served, empty outside of a request
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDCtxKey).(string)
+	return requestID
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,3 +19,29 @@ func ParseIDParam(param string, c *gin.Context) (int, error) {
 	}
 	return id, nil
 }
+
+// ParsePointInTime accepts an RFC3339 timestamp or a plain date (2006-01-02),
+// a plain date means the end of that day in UTC
+func ParsePointInTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, errors.New("time must be RFC3339 or YYYY-MM-DD")
+	}
+	return date.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// ParseRangeStart is ParsePointInTime for the lower bound of a range,
+// a plain date means the start of that day in UTC
+func ParseRangeStart(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, errors.New("time must be RFC3339 or YYYY-MM-DD")
+	}
+	return date, nil
+}